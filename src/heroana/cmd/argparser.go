@@ -6,24 +6,30 @@ import (
 	"os"
 	"strings"
 
+	"github.com/EdmilsonRodrigues/melo-project/src/melo/generator"
 )
 
 const (
 	DefaultOutputPath = "build"
+	DefaultDocsPath   = "docs"
 	BuildFlag         = "build"
+	DocsFlag          = "docs"
 	HelpFlag          = "help"
 
 	OutputFlag = "output"
+	FormatFlag = "format"
 )
 
 type (
 	HelpFunctionType  func()
 	BuildFunctionType func(inputPath string, outputPath string)
+	DocsFunctionType  func(inputPath string, outputPath string, format string)
 )
 
 var (
-	HelpFunction  HelpFunctionType = Help
+	HelpFunction  HelpFunctionType  = Help
 	BuildFunction BuildFunctionType = Build
+	DocsFunction  DocsFunctionType  = Docs
 )
 
 func ParseArguments(arguments []string) {
@@ -68,6 +74,33 @@ func ParseArguments(arguments []string) {
 		log.Printf("Building %s to %s\n", inputPath, outputPath)
 		BuildFunction(inputPath, outputPath)
 
+	case DocsFlag:
+		usage := func() {
+			fmt.Fprintf(os.Stderr, "Usage: %s %s <inputPath> [--%s <outputPath>] [--%s <%s|%s>]\n", os.Args[0], DocsFlag, OutputFlag, FormatFlag, generator.MarkdownFormat, generator.RestFormat)
+			fmt.Fprintf(os.Stderr, "Options for '%s' command:\n", DocsFlag)
+			fmt.Fprintf(os.Stderr, "  --%s <outputPath>	Output folder path\n", OutputFlag)
+			fmt.Fprintf(os.Stderr, "  --%s <format>	Documentation format, %s by default\n\n", FormatFlag, generator.MarkdownFormat)
+		}
+
+		if len(arguments) < 1 || strings.HasPrefix(arguments[0], "-") {
+			fmt.Fprint(os.Stderr, "Error: Missing input file path\n\n")
+			usage()
+			HelpFunction()
+			return
+		}
+
+		inputPath, arguments := arguments[0], arguments[1:]
+		outputPath, format, err := parseDocsArguments(arguments)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s\n\n", err.Error())
+			usage()
+			HelpFunction()
+			return
+		}
+
+		log.Printf("Documenting %s to %s\n", inputPath, outputPath)
+		DocsFunction(inputPath, outputPath, format)
+
 	case HelpFlag:
 		HelpFunction()
 
@@ -97,3 +130,28 @@ func parseBuildArguments(arguments []string) (outputPath string, err error) {
 	}
 	return
 }
+
+func parseDocsArguments(arguments []string) (outputPath string, format string, err error) {
+	outputPath, format = DefaultDocsPath, generator.MarkdownFormat
+	for len(arguments) > 0 {
+		if len(arguments) < 2 {
+			err = fmt.Errorf("error: Missing value of option %s", arguments[0])
+			return
+		}
+		switch arguments[0] {
+		case fmt.Sprintf("--%s", OutputFlag):
+			outputPath = arguments[1]
+		case fmt.Sprintf("--%s", FormatFlag):
+			format = arguments[1]
+			if format != generator.MarkdownFormat && format != generator.RestFormat {
+				err = fmt.Errorf("error: Unknown format %s", format)
+				return
+			}
+		default:
+			err = fmt.Errorf("error: Unknown option")
+			return
+		}
+		arguments = arguments[2:]
+	}
+	return
+}
@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/EdmilsonRodrigues/melo-project/src/melo/cmd"
+	"github.com/EdmilsonRodrigues/melo-project/src/melo/generator"
 )
 
 func TestParseArguments(t *testing.T) {
@@ -110,6 +111,66 @@ func TestParseArguments(t *testing.T) {
 	})
 }
 
+func TestParseDocsArguments(t *testing.T) {
+	allArguments := []struct {
+		Name                 string
+		Arguments            []string
+		ExpectedCallsHelpNum int
+		ExpectedCallDocsArgs [][]string
+	}{
+		{
+			Name:                 "Successfully document project",
+			Arguments:            []string{cmd.DocsFlag, ".", "--" + cmd.OutputFlag, "output", "--" + cmd.FormatFlag, generator.RestFormat},
+			ExpectedCallsHelpNum: 0,
+			ExpectedCallDocsArgs: [][]string{{".", "output", generator.RestFormat}},
+		},
+		{
+			Name:                 "Successfully document project with default options",
+			Arguments:            []string{cmd.DocsFlag, "."},
+			ExpectedCallsHelpNum: 0,
+			ExpectedCallDocsArgs: [][]string{{".", cmd.DefaultDocsPath, generator.MarkdownFormat}},
+		},
+		{
+			Name:                 "Print help when passing docs flag without input path",
+			Arguments:            []string{cmd.DocsFlag, "--" + cmd.OutputFlag, "output"},
+			ExpectedCallsHelpNum: 1,
+		},
+		{
+			Name:                 "Print help when passing an unknown format",
+			Arguments:            []string{cmd.DocsFlag, ".", "--" + cmd.FormatFlag, "html"},
+			ExpectedCallsHelpNum: 1,
+		},
+		{
+			Name:                 "Print help when passing format flag without format",
+			Arguments:            []string{cmd.DocsFlag, ".", "--" + cmd.FormatFlag},
+			ExpectedCallsHelpNum: 1,
+		},
+	}
+	for _, argument := range allArguments {
+		t.Run(argument.Name, func(t *testing.T) {
+			helpCalls := 0
+			docsCalls := [][]string{}
+
+			cmd.HelpFunction = spyHelpFunc(&helpCalls)
+			cmd.DocsFunction = spyDocsFunc(&docsCalls)
+
+			cmd.ParseArguments(argument.Arguments)
+
+			if helpCalls != argument.ExpectedCallsHelpNum {
+				t.Errorf("Expected %d help calls, got %d", argument.ExpectedCallsHelpNum, helpCalls)
+			}
+
+			if len(docsCalls) != len(argument.ExpectedCallDocsArgs) {
+				t.Errorf("Expected %d docs calls, got %d", len(argument.ExpectedCallDocsArgs), len(docsCalls))
+			}
+
+			if len(docsCalls) > 0 && !reflect.DeepEqual(docsCalls, argument.ExpectedCallDocsArgs) {
+				t.Errorf("Expected %+v arguments on docs calls, got %+v", argument.ExpectedCallDocsArgs, docsCalls)
+			}
+		})
+	}
+}
+
 func spyHelpFunc(num *int) cmd.HelpFunctionType {
 	return func() {
 		*num++
@@ -121,3 +182,9 @@ func spyBuildFunc(callsArgs *[][]string) cmd.BuildFunctionType {
 		*callsArgs = append(*callsArgs, []string{inputPath, outputPath})
 	}
 }
+
+func spyDocsFunc(callsArgs *[][]string) cmd.DocsFunctionType {
+	return func(inputPath string, outputPath string, format string) {
+		*callsArgs = append(*callsArgs, []string{inputPath, outputPath, format})
+	}
+}
@@ -1,24 +1,144 @@
 package cmd
 
 import (
+	"fmt"
+	"io/fs"
+	"log"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
 
 	"github.com/EdmilsonRodrigues/melo-project/src/melo/files"
+	"github.com/EdmilsonRodrigues/melo-project/src/melo/generator"
 )
 
+const (
+	// BridgeFolder is the folder of the output holding the Go module of the
+	// generated bridge.
+	BridgeFolder = "bridge"
+	// LibraryName is the shared library built from the bridge, loaded by the
+	// Python runtime from its own folder.
+	LibraryName = "libmelo.so"
 
+	bridgeModule = "melo/bridge"
+)
+
+// Build generates the Python module of every exported package of the module
+// in inputPath, along with the cgo bridge they call, and builds the bridge
+// into the shared library the modules load.
 func Build(inputPath string, outputPath string) {
 	if !files.CheckInputFolder(os.DirFS("."), inputPath) {
 		os.Exit(1)
 	}
 
+	moduleName, err := files.ReadModuleName(os.DirFS("."), inputPath)
+	if err != nil {
+		log.Println("Error:", err)
+		os.Exit(1)
+	}
+
 	if err := files.CreateOutputFolder(outputPath); err != nil {
+		log.Println("Error:", err)
 		os.Exit(1)
 	}
 
-	_, err := files.ScanModule(os.DirFS("."), inputPath, outputPath)
+	exportedPackages, err := files.ScanModule(os.DirFS("."), inputPath, moduleName)
 	if err != nil {
 		os.Exit(1)
 	}
 
-}
\ No newline at end of file
+	dependencies := make([]generator.Dependency, 0, len(exportedPackages))
+	for _, exportedPackage := range exportedPackages {
+		objects, err := generator.InspectPackage(inputPath, exportedPackage.GoPath)
+		if err != nil {
+			log.Println("Error:", err)
+			os.Exit(1)
+		}
+		if exportedPackage.Doctests {
+			objects.ExportedExamples, err = generator.InspectExamples(inputPath, exportedPackage.GoPath)
+			if err != nil {
+				log.Println("Error:", err)
+				os.Exit(1)
+			}
+		}
+		dependencies = append(dependencies, generator.Dependency{Package: exportedPackage, Objects: objects})
+	}
+
+	bridgePath := filepath.Join(outputPath, BridgeFolder)
+	generated := map[string]string{
+		filepath.Join(bridgePath, "runtime.go"): generator.BridgeRuntime,
+		filepath.Join(outputPath, "_melo.py"):   generator.PythonRuntime,
+	}
+	for _, dependency := range dependencies {
+		bridge, err := generator.GenerateBridge(dependency.Package, dependency.Objects, dependencies...)
+		if err != nil {
+			log.Println("Error:", err)
+			os.Exit(1)
+		}
+		module, err := generator.GeneratePythonModule(dependency.Package, dependency.Objects, dependencies...)
+		if err != nil {
+			log.Println("Error:", err)
+			os.Exit(1)
+		}
+		// Dotted Python paths such as mylib.sub are modules of namespace
+		// packages, written to mylib/sub.py.
+		pythonPath := dependency.Package.PythonPath
+		generated[filepath.Join(bridgePath, strings.ReplaceAll(pythonPath, ".", "_")+"_bridge.go")] = string(bridge)
+		if len(dependency.Objects.ExportedConstants) > 0 {
+			constantsTest, err := generator.GenerateConstantsTest(dependency.Package, dependency.Objects)
+			if err != nil {
+				log.Println("Error:", err)
+				os.Exit(1)
+			}
+			generated[filepath.Join(bridgePath, strings.ReplaceAll(pythonPath, ".", "_")+"_constants_test.go")] = string(constantsTest)
+		}
+		generated[filepath.Join(outputPath, filepath.FromSlash(strings.ReplaceAll(pythonPath, ".", "/"))+".py")] = module
+	}
+	for path, content := range generated {
+		if err := os.MkdirAll(filepath.Dir(path), fs.ModePerm); err != nil {
+			log.Println("Error:", err)
+			os.Exit(1)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			log.Println("Error:", err)
+			os.Exit(1)
+		}
+	}
+
+	if err := buildLibrary(inputPath, bridgePath); err != nil {
+		log.Println("Error:", err)
+		os.Exit(1)
+	}
+}
+
+// buildLibrary builds the bridge into the shared library, next to the Python
+// modules, then runs the tests generated along with the bridge. The bridge is
+// a module of its own, in a workspace along with the module it exposes, so
+// that nothing is written to the input folder.
+func buildLibrary(inputPath, bridgePath string) error {
+	modulePath, err := filepath.Abs(inputPath)
+	if err != nil {
+		return err
+	}
+	bridgePath, err = filepath.Abs(bridgePath)
+	if err != nil {
+		return err
+	}
+
+	log.Println("Building shared library...", filepath.Join(filepath.Dir(bridgePath), LibraryName))
+	for _, arguments := range [][]string{
+		{"mod", "init", bridgeModule},
+		{"work", "init", ".", modulePath},
+		{"build", "-mod=readonly", "-buildmode=c-shared", "-o", filepath.Join("..", LibraryName), "."},
+		{"test", "-mod=readonly", "."},
+	} {
+		command := exec.Command("go", arguments...)
+		command.Dir = bridgePath
+		command.Env = append(os.Environ(), "CGO_ENABLED=1", "GOWORK="+filepath.Join(bridgePath, "go.work"))
+		if output, err := command.CombinedOutput(); err != nil {
+			return fmt.Errorf("go %s: %w\n%s", strings.Join(arguments, " "), err, output)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+//go:build roundtrip
+
+package cmd_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/EdmilsonRodrigues/melo-project/src/melo/cmd"
+)
+
+// Building needs a C compiler, and calling the built modules python3:
+//
+//	go test -tags roundtrip ./cmd/
+
+const buildGreet = `// melo:package greet doctests
+
+package greet
+
+// Answer is the answer.
+const Answer = 42
+
+// Greet greets a name.
+func Greet(name string) string {
+	return "hello " + name
+}
+`
+
+const buildExample = `package greet_test
+
+import (
+	"fmt"
+
+	"example.com/build/greet"
+)
+
+func ExampleGreet() {
+	fmt.Println(greet.Greet("melo"))
+	// Output: hello melo
+}
+`
+
+func TestBuild(t *testing.T) {
+	root := t.TempDir()
+	writeModuleFile(t, filepath.Join(root, "module", "go.mod"), "module example.com/build\n\ngo 1.24\n")
+	writeModuleFile(t, filepath.Join(root, "module", "greet", "greet.go"), buildGreet)
+	writeModuleFile(t, filepath.Join(root, "module", "greet", "example_test.go"), buildExample)
+	t.Chdir(root)
+
+	cmd.Build("module", "out")
+
+	t.Run("should write the Python modules, the bridge and the shared library", func(t *testing.T) {
+		for _, path := range []string{
+			"out/_melo.py",
+			"out/greet.py",
+			"out/" + cmd.LibraryName,
+			"out/" + cmd.BridgeFolder + "/runtime.go",
+			"out/" + cmd.BridgeFolder + "/greet_bridge.go",
+			"out/" + cmd.BridgeFolder + "/greet_constants_test.go",
+		} {
+			if _, err := os.Stat(path); err != nil {
+				t.Errorf("Build should write %s, got %v", path, err)
+			}
+		}
+	})
+
+	t.Run("should not write to the input folder", func(t *testing.T) {
+		entries, err := os.ReadDir("module")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 2 {
+			t.Errorf("Build should leave the input folder as is, got %v", entries)
+		}
+	})
+
+	t.Run("should build modules calling Go", func(t *testing.T) {
+		python, err := exec.LookPath("python3")
+		if err != nil {
+			t.Skip("python3 is needed to call the built modules")
+		}
+		run := exec.Command(python, "-c", "import greet; print(greet.Greet('melo'), greet.Answer)")
+		run.Dir = "out"
+		output, err := run.CombinedOutput()
+		if err != nil || strings.TrimSpace(string(output)) != "hello melo 42" {
+			t.Errorf("the built module should greet, got %v\n%s", err, output)
+		}
+	})
+
+	t.Run("should translate the examples of doctests packages into passing doctests", func(t *testing.T) {
+		module, err := os.ReadFile("out/greet.py")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected := `>>> print(Greet("melo"))`; !strings.Contains(string(module), expected) {
+			t.Errorf("the built module should contain %q, got\n%s", expected, module)
+		}
+		python, err := exec.LookPath("python3")
+		if err != nil {
+			t.Skip("python3 is needed to run the doctests")
+		}
+		run := exec.Command(python, "-m", "doctest", "greet.py")
+		run.Dir = "out"
+		if output, err := run.CombinedOutput(); err != nil {
+			t.Errorf("the doctests of the built module should pass, got %v\n%s", err, output)
+		}
+	})
+}
+
+func writeModuleFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/EdmilsonRodrigues/melo-project/src/melo/files"
+	"github.com/EdmilsonRodrigues/melo-project/src/melo/generator"
+)
+
+// Docs writes the API reference of every exported package of the module in
+// inputPath, one page per Python module.
+func Docs(inputPath string, outputPath string, format string) {
+	if !files.CheckInputFolder(os.DirFS("."), inputPath) {
+		os.Exit(1)
+	}
+
+	moduleName, err := files.ReadModuleName(os.DirFS("."), inputPath)
+	if err != nil {
+		log.Println("Error:", err)
+		os.Exit(1)
+	}
+
+	exportedPackages, err := files.ScanModule(os.DirFS("."), inputPath, moduleName)
+	if err != nil {
+		os.Exit(1)
+	}
+
+	outputPath, err = filepath.Abs(outputPath)
+	if err != nil {
+		log.Println("Error:", err)
+		os.Exit(1)
+	}
+	if err := os.MkdirAll(outputPath, fs.ModePerm); err != nil {
+		log.Println("Error:", err)
+		os.Exit(1)
+	}
+
+	dependencies := make([]generator.Dependency, 0, len(exportedPackages))
+	for _, exportedPackage := range exportedPackages {
+		objects, err := generator.InspectPackage(inputPath, exportedPackage.GoPath)
+		if err != nil {
+			log.Println("Error:", err)
+			os.Exit(1)
+		}
+		objects.GoDeclarations, err = generator.InspectDeclarations(inputPath, exportedPackage.GoPath)
+		if err != nil {
+			log.Println("Error:", err)
+			os.Exit(1)
+		}
+		if exportedPackage.Doctests {
+			objects.ExportedExamples, err = generator.InspectExamples(inputPath, exportedPackage.GoPath)
+			if err != nil {
+				log.Println("Error:", err)
+				os.Exit(1)
+			}
+		}
+		dependencies = append(dependencies, generator.Dependency{Package: exportedPackage, Objects: objects})
+	}
+
+	extension := ".md"
+	if format == generator.RestFormat {
+		extension = ".rst"
+	}
+	for _, dependency := range dependencies {
+		docs, err := generator.GenerateDocs(dependency.Package, dependency.Objects, format, dependencies...)
+		if err != nil {
+			log.Println("Error:", err)
+			os.Exit(1)
+		}
+		if err := os.WriteFile(filepath.Join(outputPath, dependency.Package.PythonPath+extension), []byte(docs), 0o644); err != nil {
+			log.Println("Error:", err)
+			os.Exit(1)
+		}
+	}
+}
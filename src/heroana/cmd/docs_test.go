@@ -0,0 +1,72 @@
+package cmd_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/EdmilsonRodrigues/melo-project/src/melo/cmd"
+	"github.com/EdmilsonRodrigues/melo-project/src/melo/generator"
+)
+
+const docsGreet = `// melo:package greet doctests
+
+package greet
+
+// Greet greets a name.
+func Greet(name string) string {
+	return "hello " + name
+}
+`
+
+const docsExample = `package greet_test
+
+import (
+	"fmt"
+
+	"example.com/docs/greet"
+)
+
+func ExampleGreet() {
+	fmt.Println(greet.Greet("melo"))
+	// Output: hello melo
+}
+`
+
+func TestDocs(t *testing.T) {
+	root := t.TempDir()
+	for path, content := range map[string]string{
+		filepath.Join(root, "module", "go.mod"):                   "module example.com/docs\n\ngo 1.24\n",
+		filepath.Join(root, "module", "greet", "greet.go"):        docsGreet,
+		filepath.Join(root, "module", "greet", "example_test.go"): docsExample,
+	} {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Chdir(root)
+
+	t.Run("should document the examples of doctests packages", func(t *testing.T) {
+		for _, page := range []struct {
+			Format   string
+			Path     string
+			Expected string
+		}{
+			{generator.MarkdownFormat, "docs/greet.md", "Examples:\n\n```pycon\n>>> print(Greet(\"melo\"))\nhello melo\n```\n"},
+			{generator.RestFormat, "docs/greet.rst", "   Examples:\n       >>> print(Greet(\"melo\"))\n       hello melo\n"},
+		} {
+			cmd.Docs("module", "docs", page.Format)
+			docs, err := os.ReadFile(page.Path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(string(docs), page.Expected) {
+				t.Errorf("Docs should write %q to %s, got\n%s", page.Expected, page.Path, docs)
+			}
+		}
+	})
+}
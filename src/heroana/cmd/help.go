@@ -10,5 +10,6 @@ func Help() {
 	fmt.Print("This will generate a python module ready to be exported, where your go code will be run transparently.\n\n")
 	fmt.Println("Commands:")
 	fmt.Printf("  %s <inputPath> [--%s <outputPath>] \tBuild your project\n", BuildFlag, OutputFlag)
+	fmt.Printf("  %s <inputPath> [--%s <outputPath>] [--%s <format>] \tGenerate the API reference of your project\n", DocsFlag, OutputFlag, FormatFlag)
 	fmt.Printf("  %s \t\t\t\t\t\tPrints this message\n\n", HelpFlag)
 }
\ No newline at end of file
@@ -0,0 +1,153 @@
+package files
+
+import (
+	"fmt"
+	"go/token"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// The keyword and options of the package directive, such as
+// "// melo:package mylib.sub name_style=snake exclude=Foo,Bar".
+const (
+	PackageKeyword  = "package"
+	NameStyleOption = "name_style"
+	ExcludeOption   = "exclude"
+	GoNameStyle     = "go"
+	SnakeNameStyle  = "snake"
+)
+
+// The directives of declarations, written in their doc comment, such as
+// "// melo:name py_name".
+const (
+	IgnoreDirective      = "ignore"
+	NameDirective        = "name"
+	ReadOnlyDirective    = "readonly"
+	AsyncDirective       = "async"
+	ASGIDirective        = "asgi"
+	InstantiateDirective = "instantiate"
+)
+
+// DeclarationDirectives are the directives applying to declarations rather
+// than packages.
+var DeclarationDirectives = []string{IgnoreDirective, NameDirective, ReadOnlyDirective, AsyncDirective, ASGIDirective, InstantiateDirective}
+
+var (
+	pythonPathPattern = regexp.MustCompile(`^[A-Za-z_]\w*(\.[A-Za-z_]\w*)*$`)
+	goNamePattern     = regexp.MustCompile(`^[A-Za-z_]\w*(\.[A-Za-z_]\w*)?$`)
+)
+
+// PackageDirective is the "// melo:" comment exporting a package, along with
+// its position for diagnostics. An empty PythonPath defaults to the name of
+// the package.
+type PackageDirective struct {
+	PythonPath string
+	JSONNames  bool
+	Doctests   bool
+	NameStyle  string
+	Exclude    []string
+	Position   token.Position
+}
+
+// directiveField is a space separated word of a directive, along with its
+// byte offset for diagnostics.
+type directiveField struct {
+	Text   string
+	Offset int
+}
+
+// ParsePackageDirective parses the text following "// melo:" of a package
+// directive found at position. It is either "package", optionally followed
+// by the Python path, or the Python path alone, then the options:
+//
+//	// melo:package mylib.sub name_style=snake exclude=Foo,Bar.Baz
+//	// melo:mylib.sub json_names doctests
+func ParsePackageDirective(text string, position token.Position) (PackageDirective, error) {
+	directive := PackageDirective{Position: position}
+	errorf := func(field directiveField, format string, arguments ...any) error {
+		fieldPosition := position
+		fieldPosition.Column += len(GoExportedDirective) + field.Offset
+		fieldPosition.Offset += len(GoExportedDirective) + field.Offset
+		return fmt.Errorf("%s: %s", fieldPosition, fmt.Sprintf(format, arguments...))
+	}
+
+	fields := splitDirective(text)
+	if len(fields) > 0 && fields[0].Text == PackageKeyword {
+		fields = fields[1:]
+		if len(fields) > 0 && (strings.Contains(fields[0].Text, "=") || isPackageFlag(fields[0].Text)) {
+			fields = append([]directiveField{{}}, fields...)
+		}
+	} else if len(fields) > 0 && slices.Contains(DeclarationDirectives, fields[0].Text) {
+		return directive, errorf(fields[0], "the %q directive applies to declarations, not to packages", fields[0].Text)
+	}
+	if len(fields) == 0 {
+		return directive, nil
+	}
+
+	path := fields[0]
+	fields = fields[1:]
+	if path.Text != "" && !pythonPathPattern.MatchString(path.Text) {
+		return directive, errorf(path, "%q is not a Python module path", path.Text)
+	}
+	directive.PythonPath = path.Text
+
+	seen := map[string]bool{}
+	for _, field := range fields {
+		key, value, hasValue := strings.Cut(field.Text, "=")
+		if seen[key] {
+			return directive, errorf(field, "duplicate option %q", key)
+		}
+		seen[key] = true
+
+		switch key {
+		case JSONNamesOption, DoctestsOption:
+			if hasValue {
+				return directive, errorf(field, "option %q takes no value", key)
+			}
+			directive.JSONNames = directive.JSONNames || key == JSONNamesOption
+			directive.Doctests = directive.Doctests || key == DoctestsOption
+		case NameStyleOption:
+			if value != GoNameStyle && value != SnakeNameStyle {
+				return directive, errorf(field, "option %q must be %q or %q, got %q", key, GoNameStyle, SnakeNameStyle, value)
+			}
+			directive.NameStyle = value
+		case ExcludeOption:
+			if value == "" {
+				return directive, errorf(field, "option %q needs a comma separated list of Go names", key)
+			}
+			for _, name := range strings.Split(value, ",") {
+				if !goNamePattern.MatchString(name) {
+					return directive, errorf(field, "%q is not a Go name such as Name or Type.Member", name)
+				}
+				directive.Exclude = append(directive.Exclude, name)
+			}
+		default:
+			return directive, errorf(field, "unknown option %q", key)
+		}
+	}
+	return directive, nil
+}
+
+func isPackageFlag(text string) bool {
+	return text == JSONNamesOption || text == DoctestsOption
+}
+
+// splitDirective splits a directive on spaces, keeping the offset of every
+// field.
+func splitDirective(text string) []directiveField {
+	fields := []directiveField{}
+	start := -1
+	for index, character := range text + " " {
+		switch {
+		case character == ' ' || character == '\t':
+			if start >= 0 {
+				fields = append(fields, directiveField{Text: text[start:index], Offset: start})
+				start = -1
+			}
+		case start < 0:
+			start = index
+		}
+	}
+	return fields
+}
@@ -2,8 +2,11 @@ package files
 
 import (
 	"fmt"
+	"go/parser"
+	"go/token"
 	"io/fs"
 	"log"
+	"path"
 	"strings"
 )
 
@@ -11,15 +14,30 @@ type ExportedPackage struct {
 	GoPath      string
 	PythonPath  string
 	PackageName string
+	// JSONFieldNames names the Python attributes of struct fields after their
+	// json tags, enabled by the json_names option of the package directive.
+	JSONFieldNames bool
+	// Doctests translates the Go Example functions of the package into
+	// doctests, enabled by the doctests option of the package directive.
+	Doctests bool
+	// NameStyle is the spelling of the Python names of functions, methods
+	// and fields, GoNameStyle when empty, set by the name_style option.
+	NameStyle string
+	// Exclude lists the declarations left out of the Python module, such as
+	// Name or Type.Member, set by the exclude option.
+	Exclude []string
 }
 
 const (
 	GoExportedDirective = "// melo:"
+	JSONNamesOption     = "json_names"
+	DoctestsOption      = "doctests"
 )
 
-func ScanModule(fileSystem fs.FS, path, moduleName string) ([]ExportedPackage, error) {
+func ScanModule(fileSystem fs.FS, modulePath, moduleName string) ([]ExportedPackage, error) {
 	exportedPackages := []ExportedPackage{}
-	err := fs.WalkDir(fileSystem, path, getScanModuleWalker(fileSystem, &exportedPackages, moduleName))
+	modulePath = path.Clean(modulePath)
+	err := fs.WalkDir(fileSystem, modulePath, getScanModuleWalker(fileSystem, &exportedPackages, modulePath, moduleName))
 
 	if err != nil {
 		log.Printf("Error scanning module: %v", err)
@@ -29,7 +47,7 @@ func ScanModule(fileSystem fs.FS, path, moduleName string) ([]ExportedPackage, e
 	return exportedPackages, nil
 }
 
-func getScanModuleWalker(fileSystem fs.FS, exportedPackages *[]ExportedPackage, moduleName string) fs.WalkDirFunc {
+func getScanModuleWalker(fileSystem fs.FS, exportedPackages *[]ExportedPackage, modulePath, moduleName string) fs.WalkDirFunc {
 	return func(path string, dirEntry fs.DirEntry, err error) error {
 		if err != nil {
 			return fmt.Errorf("error reading %s: %w", path, err)
@@ -47,55 +65,87 @@ func getScanModuleWalker(fileSystem fs.FS, exportedPackages *[]ExportedPackage,
 			return nil
 		}
 
-		packageName, pythonPath, exported, err := parseGoFile(fileSystem, path)
+		packageName, directive, err := parseGoFile(fileSystem, path)
 		if err != nil {
 			return fmt.Errorf("error parsing %s: %w", path, err)
 		}
 
-		if !exported {
+		if directive == nil {
 			return nil
 		}
+		if directive.PythonPath == "" {
+			directive.PythonPath = packageName
+		}
+		log.Printf("Exporting package %s as %q, directive at %s", packageName, directive.PythonPath, directive.Position)
 
-		exportedPackage := genExportedPackage(path, moduleName, packageName, pythonPath)
+		exportedPackage := genExportedPackage(goFormatPath(modulePath, path, moduleName), packageName, directive.PythonPath)
+		exportedPackage.JSONFieldNames = directive.JSONNames
+		exportedPackage.Doctests = directive.Doctests
+		exportedPackage.NameStyle = directive.NameStyle
+		exportedPackage.Exclude = directive.Exclude
 
 		*exportedPackages = append(*exportedPackages, exportedPackage)
 		return nil
 	}
 }
 
-func goFormatPath(path, moduleName string) string {
-	splittedPath := strings.Split(path, "/")
-	splittedPath = splittedPath[1 : len(splittedPath)-1]
-	log.Println(splittedPath)
-
-	return strings.Join(append([]string{moduleName}, splittedPath...), "/")
+// goFormatPath is the import path of the package of a file, from the path of
+// its folder relative to the root of the module.
+func goFormatPath(modulePath, filePath, moduleName string) string {
+	folder := path.Dir(filePath)
+	if modulePath != "." {
+		folder = strings.TrimPrefix(strings.TrimPrefix(folder, modulePath), "/")
+	}
+	if folder == "." || folder == "" {
+		return moduleName
+	}
+	return moduleName + "/" + folder
 }
 
-func parseGoFile(fileSystem fs.FS, filePath string) (packageName string, pythonPath string, exported bool, err error) {
+// parseGoFile parses the package clause of a Go file and the directive found
+// in the comments leading it, if any. Comments elsewhere, such as after the
+// package name or inside block comments, are not directives.
+func parseGoFile(fileSystem fs.FS, filePath string) (packageName string, directive *PackageDirective, err error) {
 	content, err := fs.ReadFile(fileSystem, filePath)
 	if err != nil {
 		return
 	}
 
-	for _, line := range strings.Split(string(content), "\n") {
-		if strings.HasPrefix(line, GoExportedDirective) {
-			pythonPath = strings.TrimPrefix(line, GoExportedDirective)
-			exported = true
-		} else if strings.HasPrefix(line, "package ") {
-			packageName = strings.TrimPrefix(line, "package ")
-			break
-		}
+	fileSet := token.NewFileSet()
+	file, err := parser.ParseFile(fileSet, filePath, content, parser.PackageClauseOnly|parser.ParseComments)
+	if err != nil {
+		return
 	}
+	packageName = file.Name.Name
 
-	if pythonPath != "" {
-		exported = true
+	for _, group := range file.Comments {
+		if group.End() >= file.Package {
+			break
+		}
+		for _, line := range group.List {
+			text, ok := strings.CutPrefix(line.Text, GoExportedDirective)
+			if !ok {
+				continue
+			}
+			position := fileSet.Position(line.Slash)
+			if directive != nil {
+				err = fmt.Errorf("%s: duplicate %q directive, already declared at %s", position, GoExportedDirective, directive.Position)
+				return
+			}
+			parsed, parseErr := ParsePackageDirective(text, position)
+			if parseErr != nil {
+				err = parseErr
+				return
+			}
+			directive = &parsed
+		}
 	}
 	return
 }
 
-func genExportedPackage(path, moduleName, packageName, pythonPath string) ExportedPackage {
+func genExportedPackage(goPath, packageName, pythonPath string) ExportedPackage {
 	exportedPackege := ExportedPackage{
-		GoPath:     goFormatPath(path, moduleName),
+		GoPath:     goPath,
 		PythonPath: pythonPath,
 	}
 
@@ -2,8 +2,10 @@ package files_test
 
 import (
 	"fmt"
+	"go/token"
 	"io/fs"
 	"reflect"
+	"strings"
 	"testing"
 	"testing/fstest"
 
@@ -65,3 +67,261 @@ func TestScanModule(t *testing.T) {
 		}
 	})
 }
+
+func TestScanModulePaths(t *testing.T) {
+	directive := files.GoExportedDirective
+
+	t.Run("should resolve import paths from the current folder", func(t *testing.T) {
+		fs := fstest.MapFS{
+			"go.mod":           {Data: []byte("module example.com\n\ngo 1.24.0\n")},
+			"root.go":          {Data: []byte(fmt.Sprintf("%smypackage\n\npackage example\n", directive))},
+			"nested":           {Mode: fs.ModeDir},
+			"nested/deep":      {Mode: fs.ModeDir},
+			"nested/deep/a.go": {Data: []byte(fmt.Sprintf("%smypackage.deep\n\npackage deep\n", directive))},
+		}
+
+		for _, modulePath := range []string{".", "./"} {
+			exportedPackages, err := files.ScanModule(fs, modulePath, "example.com")
+			if err != nil {
+				t.Errorf("ScanModule should not return error, got %v", err)
+			}
+
+			expected := []files.ExportedPackage{
+				{GoPath: "example.com/nested/deep", PythonPath: "mypackage.deep"},
+				{GoPath: "example.com", PythonPath: "mypackage", PackageName: "example"},
+			}
+			if !reflect.DeepEqual(exportedPackages, expected) {
+				t.Errorf("ScanModule(%q) should return %+v, got %+v", modulePath, expected, exportedPackages)
+			}
+		}
+	})
+
+	t.Run("should resolve import paths from a nested module folder", func(t *testing.T) {
+		fs := fstest.MapFS{
+			"rv4":                    {Mode: fs.ModeDir},
+			"rv4/proj":               {Mode: fs.ModeDir},
+			"rv4/proj/go.mod":        {Data: []byte("module example.com/proj\n\ngo 1.24.0\n")},
+			"rv4/proj/api":           {Mode: fs.ModeDir},
+			"rv4/proj/api/v1":        {Mode: fs.ModeDir},
+			"rv4/proj/api/v1/api.go": {Data: []byte(fmt.Sprintf("%smypackage.api\n\npackage v1\n", directive))},
+		}
+
+		exportedPackages, err := files.ScanModule(fs, "rv4/proj/", "example.com/proj")
+		if err != nil {
+			t.Errorf("ScanModule should not return error, got %v", err)
+		}
+
+		expected := []files.ExportedPackage{
+			{GoPath: "example.com/proj/api/v1", PythonPath: "mypackage.api"},
+		}
+		if !reflect.DeepEqual(exportedPackages, expected) {
+			t.Errorf("ScanModule should return %+v, got %+v", expected, exportedPackages)
+		}
+	})
+}
+
+func TestScanModuleOptions(t *testing.T) {
+	t.Run("should read package options after the python path", func(t *testing.T) {
+		fs := fstest.MapFS{
+			"root":                {Mode: fs.ModeDir},
+			"root/tagged":         {Mode: fs.ModeDir},
+			"root/tagged/tags.go": {Data: []byte(fmt.Sprintf("%smypackage.tagged %s\n\npackage tagged\n", files.GoExportedDirective, files.JSONNamesOption))},
+		}
+
+		exportedPackages, err := files.ScanModule(fs, "root", "example.com")
+		if err != nil {
+			t.Errorf("ScanModule should not return error, got %v", err)
+		}
+
+		expected := []files.ExportedPackage{
+			{
+				GoPath:         "example.com/tagged",
+				PythonPath:     "mypackage.tagged",
+				JSONFieldNames: true,
+			},
+		}
+		if !reflect.DeepEqual(exportedPackages, expected) {
+			t.Errorf("ScanModule should return %+v, got %+v", expected, exportedPackages)
+		}
+	})
+
+	t.Run("should read the doctests option", func(t *testing.T) {
+		fs := fstest.MapFS{
+			"root":                   {Mode: fs.ModeDir},
+			"root/example":           {Mode: fs.ModeDir},
+			"root/example/sample.go": {Data: []byte(fmt.Sprintf("%smypackage.example %s %s\n\npackage example\n", files.GoExportedDirective, files.JSONNamesOption, files.DoctestsOption))},
+		}
+
+		exportedPackages, err := files.ScanModule(fs, "root", "example.com")
+		if err != nil {
+			t.Errorf("ScanModule should not return error, got %v", err)
+		}
+
+		expected := []files.ExportedPackage{
+			{
+				GoPath:         "example.com/example",
+				PythonPath:     "mypackage.example",
+				JSONFieldNames: true,
+				Doctests:       true,
+			},
+		}
+		if !reflect.DeepEqual(exportedPackages, expected) {
+			t.Errorf("ScanModule should return %+v, got %+v", expected, exportedPackages)
+		}
+	})
+}
+
+func TestScanModuleDirectives(t *testing.T) {
+	directive := files.GoExportedDirective
+
+	t.Run("should only read directives leading the package clause", func(t *testing.T) {
+		fs := fstest.MapFS{
+			"root":                  {Mode: fs.ModeDir},
+			"root/commented":        {Mode: fs.ModeDir},
+			"root/commented/a.go":   {Data: []byte(fmt.Sprintf("%smypackage.commented\n\npackage commented // the commented package\n", directive))},
+			"root/constrained":      {Mode: fs.ModeDir},
+			"root/constrained/a.go": {Data: []byte(fmt.Sprintf("//go:build linux\n\n%smypackage.constrained\n\npackage constrained\n", directive))},
+			"root/bom":              {Mode: fs.ModeDir},
+			"root/bom/a.go":         {Data: []byte(fmt.Sprintf("\ufeff%smypackage.bom\n\npackage bom\n", directive))},
+			"root/block":            {Mode: fs.ModeDir},
+			"root/block/a.go":       {Data: []byte(fmt.Sprintf("/*\n%smypackage.block\n*/\n\npackage block\n", directive))},
+			"root/raw":              {Mode: fs.ModeDir},
+			"root/raw/a.go":         {Data: []byte(fmt.Sprintf("package raw\n\nconst Source = `\n%smypackage.raw\n`\n", directive))},
+		}
+
+		exportedPackages, err := files.ScanModule(fs, "root", "example.com")
+		if err != nil {
+			t.Errorf("ScanModule should not return error, got %v", err)
+		}
+
+		expected := []files.ExportedPackage{
+			{GoPath: "example.com/bom", PythonPath: "mypackage.bom"},
+			{GoPath: "example.com/commented", PythonPath: "mypackage.commented"},
+			{GoPath: "example.com/constrained", PythonPath: "mypackage.constrained"},
+		}
+		if !reflect.DeepEqual(exportedPackages, expected) {
+			t.Errorf("ScanModule should return %+v, got %+v", expected, exportedPackages)
+		}
+	})
+
+	t.Run("should report the position of duplicate directives", func(t *testing.T) {
+		fs := fstest.MapFS{
+			"root":                {Mode: fs.ModeDir},
+			"root/twice":          {Mode: fs.ModeDir},
+			"root/twice/twice.go": {Data: []byte(fmt.Sprintf("// Package twice.\n%smypackage.twice\n\n%smypackage.again\npackage twice\n", directive, directive))},
+		}
+
+		_, err := files.ScanModule(fs, "root", "example.com")
+		if err == nil || !strings.Contains(err.Error(), "root/twice/twice.go:4:1: duplicate") || !strings.Contains(err.Error(), "root/twice/twice.go:2:1") {
+			t.Errorf("ScanModule should report both directive positions, got %v", err)
+		}
+	})
+
+	t.Run("should default the Python path to the package name", func(t *testing.T) {
+		fs := fstest.MapFS{
+			"root":          {Mode: fs.ModeDir},
+			"root/sub":      {Mode: fs.ModeDir},
+			"root/sub/a.go": {Data: []byte(fmt.Sprintf("%spackage name_style=snake exclude=Foo\n\npackage sub\n", directive))},
+		}
+
+		exportedPackages, err := files.ScanModule(fs, "root", "example.com")
+		if err != nil {
+			t.Errorf("ScanModule should not return error, got %v", err)
+		}
+
+		expected := []files.ExportedPackage{
+			{GoPath: "example.com/sub", PythonPath: "sub", NameStyle: files.SnakeNameStyle, Exclude: []string{"Foo"}},
+		}
+		if !reflect.DeepEqual(exportedPackages, expected) {
+			t.Errorf("ScanModule should return %+v, got %+v", expected, exportedPackages)
+		}
+	})
+
+	t.Run("should report the position of unknown options", func(t *testing.T) {
+		fs := fstest.MapFS{
+			"root":          {Mode: fs.ModeDir},
+			"root/sub":      {Mode: fs.ModeDir},
+			"root/sub/a.go": {Data: []byte(fmt.Sprintf("// Package sub.\n%smypackage.sub json_name\npackage sub\n", directive))},
+		}
+
+		_, err := files.ScanModule(fs, "root", "example.com")
+		if err == nil || !strings.Contains(err.Error(), "root/sub/a.go:2:23: unknown option \"json_name\"") {
+			t.Errorf("ScanModule should report the unknown option position, got %v", err)
+		}
+	})
+
+	t.Run("should report the position of syntax errors", func(t *testing.T) {
+		fs := fstest.MapFS{
+			"root":             {Mode: fs.ModeDir},
+			"root/broken":      {Mode: fs.ModeDir},
+			"root/broken/a.go": {Data: []byte(fmt.Sprintf("%smypackage.broken\n\npackage\n", directive))},
+		}
+
+		_, err := files.ScanModule(fs, "root", "example.com")
+		if err == nil || !strings.Contains(err.Error(), "root/broken/a.go:3:9") {
+			t.Errorf("ScanModule should report the syntax error position, got %v", err)
+		}
+	})
+}
+
+func TestParsePackageDirective(t *testing.T) {
+	position := token.Position{Filename: "root/sub/a.go", Line: 3, Column: 1}
+
+	t.Run("should parse the package keyword, the Python path and the options", func(t *testing.T) {
+		directive, err := files.ParsePackageDirective("package mylib.sub name_style=snake exclude=Foo,Bar.Baz json_names doctests", position)
+		if err != nil {
+			t.Fatalf("ParsePackageDirective should not return error, got %v", err)
+		}
+
+		expected := files.PackageDirective{
+			PythonPath: "mylib.sub",
+			JSONNames:  true,
+			Doctests:   true,
+			NameStyle:  files.SnakeNameStyle,
+			Exclude:    []string{"Foo", "Bar.Baz"},
+			Position:   position,
+		}
+		if !reflect.DeepEqual(directive, expected) {
+			t.Errorf("ParsePackageDirective should return %+v, got %+v", expected, directive)
+		}
+	})
+
+	t.Run("should parse the Python path without the package keyword", func(t *testing.T) {
+		directive, err := files.ParsePackageDirective("mylib.sub json_names", position)
+		if err != nil {
+			t.Fatalf("ParsePackageDirective should not return error, got %v", err)
+		}
+		if directive.PythonPath != "mylib.sub" || !directive.JSONNames {
+			t.Errorf("ParsePackageDirective should read mylib.sub with json_names, got %+v", directive)
+		}
+	})
+
+	t.Run("should leave the Python path empty when only options follow the keyword", func(t *testing.T) {
+		directive, err := files.ParsePackageDirective("package doctests", position)
+		if err != nil {
+			t.Fatalf("ParsePackageDirective should not return error, got %v", err)
+		}
+		if directive.PythonPath != "" || !directive.Doctests {
+			t.Errorf("ParsePackageDirective should read doctests without Python path, got %+v", directive)
+		}
+	})
+
+	t.Run("should report malformed directives with the position of the field", func(t *testing.T) {
+		for text, expected := range map[string]string{
+			"package mylib colour=red":           "root/sub/a.go:3:23: unknown option \"colour\"",
+			"package mylib name_style=camel":     "root/sub/a.go:3:23: option \"name_style\" must be \"go\" or \"snake\", got \"camel\"",
+			"package mylib json_names=yes":       "root/sub/a.go:3:23: option \"json_names\" takes no value",
+			"package mylib doctests doctests":    "root/sub/a.go:3:32: duplicate option \"doctests\"",
+			"package mylib exclude=":             "root/sub/a.go:3:23: option \"exclude\" needs a comma separated list of Go names",
+			"package mylib exclude=Foo,a.b.c":    "root/sub/a.go:3:23: \"a.b.c\" is not a Go name such as Name or Type.Member",
+			"package my-lib":                     "root/sub/a.go:3:17: \"my-lib\" is not a Python module path",
+			"mylib.sub name_style=snake extra=1": "root/sub/a.go:3:36: unknown option \"extra\"",
+			"readonly":                           "root/sub/a.go:3:9: the \"readonly\" directive applies to declarations, not to packages",
+		} {
+			_, err := files.ParsePackageDirective(text, position)
+			if err == nil || err.Error() != expected {
+				t.Errorf("ParsePackageDirective(%q) should return %q, got %v", text, expected, err)
+			}
+		}
+	})
+}
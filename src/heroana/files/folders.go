@@ -1,9 +1,11 @@
 package files
 
 import (
+	"fmt"
 	"io/fs"
 	"log"
 	"os"
+	"path"
 	"strings"
 )
 
@@ -13,7 +15,7 @@ const (
 
 func CheckInputFolder(fileSystem fs.FS, path string) bool {
 	log.Println("Checking input folder...", path)
-	content, err := fs.ReadFile(fileSystem, strings.TrimRight(path, "/") + "/go.mod")
+	content, err := fs.ReadFile(fileSystem, goModPath(path))
 	if err != nil {
 		log.Println("Error:", err)
 		return false
@@ -30,4 +32,25 @@ func CheckInputFolder(fileSystem fs.FS, path string) bool {
 func CreateOutputFolder(path string) error {
 	log.Println("Creating output folder...", path)
 	return os.Mkdir(path, fs.ModePerm)
-}
\ No newline at end of file
+}
+
+// ReadModuleName reads the module path declared by the go.mod of a folder.
+func ReadModuleName(fileSystem fs.FS, path string) (string, error) {
+	content, err := fs.ReadFile(fileSystem, goModPath(path))
+	if err != nil {
+		return "", err
+	}
+
+	for _, line := range strings.Split(string(content), "\n") {
+		if moduleName, ok := strings.CutPrefix(strings.TrimSpace(line), goModStart); ok {
+			return strings.Trim(strings.TrimSpace(moduleName), "\""), nil
+		}
+	}
+	return "", fmt.Errorf("no module directive in %s", goModPath(path))
+}
+
+// goModPath is the path of the go.mod of a folder, "." being the root of the
+// file system.
+func goModPath(folder string) string {
+	return path.Join(folder, "go.mod")
+}
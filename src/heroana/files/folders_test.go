@@ -40,3 +40,43 @@ func TestCheckInputFolder(t *testing.T) {
 		}
 	})
 }
+
+func TestReadModuleName(t *testing.T) {
+	fs := fstest.MapFS{
+		"root":                  {Mode: fs.ModeDir},
+		"root/module":           {Mode: fs.ModeDir},
+		"root/module/go.mod":    {Data: []byte("module example.com/project\n\ngo 1.24.0\n")},
+		"root/no_module":        {Mode: fs.ModeDir},
+		"root/no_module/go.mod": {Data: []byte("go 1.24.0\n")},
+	}
+
+	t.Run("should read the module path", func(t *testing.T) {
+		moduleName, err := files.ReadModuleName(fs, "root/module/")
+		if err != nil {
+			t.Errorf("ReadModuleName should not return error, got %v", err)
+		}
+		if moduleName != "example.com/project" {
+			t.Errorf("ReadModuleName should return %q, got %q", "example.com/project", moduleName)
+		}
+	})
+
+	t.Run("should read the module path of the current folder", func(t *testing.T) {
+		fs := fstest.MapFS{"go.mod": {Data: []byte("module example.com/current\n")}}
+		moduleName, err := files.ReadModuleName(fs, ".")
+		if err != nil {
+			t.Errorf("ReadModuleName should not return error, got %v", err)
+		}
+		if moduleName != "example.com/current" {
+			t.Errorf("ReadModuleName should return %q, got %q", "example.com/current", moduleName)
+		}
+		if !files.CheckInputFolder(fs, ".") {
+			t.Errorf("CheckInputFolder should return true for the current folder")
+		}
+	})
+
+	t.Run("should return an error without module directive", func(t *testing.T) {
+		if _, err := files.ReadModuleName(fs, "root/no_module"); err == nil {
+			t.Errorf("ReadModuleName should return an error")
+		}
+	})
+}
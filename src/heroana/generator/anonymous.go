@@ -0,0 +1,221 @@
+package generator
+
+import (
+	"fmt"
+	"go/ast"
+	"log"
+	"strings"
+)
+
+// resolveFunction resolves an anonymous func type, such as func(int) error.
+// Python callables passed to Go are called back like interface methods, and
+// functions returned by Go are wrapped in callable Python objects.
+func (context *generatorContext) resolveFunction(typeName string) bridgeType {
+	unsupported := bridgeType{kind: unsupportedKind, goType: typeName}
+	parameters, results, ok := splitSignature(typeName)
+	if !ok {
+		return unsupported
+	}
+
+	signature := &bridgeCall{}
+	goParameters := make([]string, 0, len(parameters))
+	for index, parameter := range parameters {
+		parameterType := context.resolveType(parameter)
+		if !parameterType.storable() {
+			return unsupported
+		}
+		signature.Arguments = append(signature.Arguments, bridgeArgument{Name: fmt.Sprintf("arg%d", index), Type: parameterType})
+		goParameters = append(goParameters, parameterType.goType)
+	}
+
+	if len(results) > 0 && results[len(results)-1] == "error" {
+		signature.ReturnsError = true
+		results = results[:len(results)-1]
+	}
+	goResults := make([]string, 0, len(results)+1)
+	for _, result := range results {
+		resultType := context.resolveType(result)
+		if !resultType.storable() {
+			return unsupported
+		}
+		signature.Results = append(signature.Results, resultType)
+		goResults = append(goResults, resultType.goType)
+	}
+	if signature.ReturnsError {
+		goResults = append(goResults, "error")
+	}
+
+	goType := "func(" + strings.Join(goParameters, ", ") + ")"
+	switch len(goResults) {
+	case 0:
+	case 1:
+		goType += " " + goResults[0]
+	default:
+		goType += " (" + strings.Join(goResults, ", ") + ")"
+	}
+	return bridgeType{kind: functionKind, goType: goType, name: functionKey(goType), signature: signature}
+}
+
+// resolveAnonymousStruct resolves an anonymous struct type, such as
+// struct{A int}, whose fields cross the bridge one by one. Its Python name is
+// settled by resolveRoutine, which knows where the struct appears.
+func (context *generatorContext) resolveAnonymousStruct(typeName string) bridgeType {
+	unsupported := bridgeType{kind: unsupportedKind, goType: typeName}
+	body, ok := strings.CutPrefix(typeName, "struct{")
+	if !ok || !strings.HasSuffix(body, "}") {
+		return unsupported
+	}
+	body = strings.TrimSuffix(body, "}")
+
+	anonymous := bridgeType{kind: anonymousStructKind}
+	goFields := []string{}
+	for _, field := range splitTopLevel(body, ';') {
+		tokens := splitTopLevel(field, ' ')
+		if len(tokens) < 2 || !isExportedIdentifier(tokens[0]) {
+			return unsupported
+		}
+		tag := ""
+		if last := tokens[len(tokens)-1]; strings.HasPrefix(last, "\"") {
+			tag, tokens = " "+last, tokens[:len(tokens)-1]
+		}
+		fieldType := context.resolveType(strings.Join(tokens[1:], " "))
+		if !fieldType.storable() {
+			return unsupported
+		}
+		anonymous.fields = append(anonymous.fields, bridgeArgument{Name: tokens[0], Type: fieldType})
+		goFields = append(goFields, tokens[0]+" "+fieldType.goType+tag)
+	}
+	anonymous.goType = "struct{" + strings.Join(goFields, "; ") + "}"
+	return anonymous
+}
+
+// nameAnonymousStruct names the TypedDict of an anonymous struct after the
+// routine and the argument or result it types, and registers it.
+func (context *generatorContext) nameAnonymousStruct(anonymous bridgeType, names ...string) bridgeType {
+	anonymous.name = strings.Join(append(names, "Struct"), "_")
+	anonymous.symbol = context.symbol("struct", anonymous.name)
+	for _, used := range context.helpers {
+		if used.symbol == anonymous.symbol && used.goType != anonymous.goType {
+			log.Printf("Skipping struct %s: another anonymous struct has the same name", anonymous.name)
+			return bridgeType{kind: unsupportedKind, goType: anonymous.goType}
+		}
+	}
+	return context.useHelper(anonymous)
+}
+
+// fieldParameters are the names of the C parameters carrying the fields of
+// an anonymous struct.
+func (bridge bridgeType) fieldParameters(expression string) []string {
+	parameters := make([]string, 0, len(bridge.fields))
+	for index := range bridge.fields {
+		parameters = append(parameters, fmt.Sprintf("%sF%d", expression, index))
+	}
+	return parameters
+}
+
+// functionKey is an identifier naming the helpers of a func type.
+func functionKey(goType string) string {
+	replacer := strings.NewReplacer("func(", "", ")", "", "(", "", ", ", "_", " ", "_to_", "*", "ptr", ".", "_")
+	key := sanitizeIdentifier(replacer.Replace(goType))
+	if key == "" {
+		return "empty"
+	}
+	return key
+}
+
+// splitSignature splits the parameter and result types of a func type, as
+// printed by go/types with or without parameter names. Variadic functions
+// are not supported.
+func splitSignature(typeName string) (parameters, results []string, ok bool) {
+	rest, ok := strings.CutPrefix(typeName, "func(")
+	if !ok {
+		return nil, nil, false
+	}
+	end := closingIndex(rest)
+	if end < 0 {
+		return nil, nil, false
+	}
+	parameters, ok = unnamedTypes(splitTopLevel(rest[:end], ','))
+	if !ok {
+		return nil, nil, false
+	}
+
+	resultList := strings.TrimSpace(rest[end+1:])
+	if strings.HasPrefix(resultList, "(") && closingIndex(resultList[1:]) == len(resultList)-2 {
+		results, ok = unnamedTypes(splitTopLevel(resultList[1:len(resultList)-1], ','))
+	} else if resultList != "" {
+		results = []string{resultList}
+	}
+	return parameters, results, ok
+}
+
+// unnamedTypes drops the names of parameters, which go/types prints for all
+// parameters or none.
+func unnamedTypes(parameters []string) ([]string, bool) {
+	types := make([]string, 0, len(parameters))
+	for _, parameter := range parameters {
+		parameter = strings.TrimSpace(parameter)
+		if tokens := splitTopLevel(parameter, ' '); len(tokens) > 1 && isIdentifier(tokens[0]) && tokens[0] != "chan" {
+			parameter = strings.Join(tokens[1:], " ")
+		}
+		if strings.HasPrefix(parameter, "...") {
+			return nil, false
+		}
+		types = append(types, parameter)
+	}
+	return types, true
+}
+
+// closingIndex returns the index of the parenthesis closing an already opened
+// one, or -1.
+func closingIndex(value string) int {
+	depth := 1
+	for index, character := range value {
+		switch character {
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+			if depth == 0 {
+				return index
+			}
+		}
+	}
+	return -1
+}
+
+// splitTopLevel splits a type string on a separator outside of brackets and
+// quoted struct tags, dropping empty parts.
+func splitTopLevel(value string, separator rune) []string {
+	parts := []string{}
+	depth, start, quoted, escaped := 0, 0, false, false
+	for index, character := range value + string(separator) {
+		switch {
+		case escaped:
+			escaped = false
+		case quoted && character == '\\':
+			escaped = true
+		case character == '"':
+			quoted = !quoted
+		case quoted:
+		case character == '(' || character == '[' || character == '{':
+			depth++
+		case character == ')' || character == ']' || character == '}':
+			depth--
+		case character == separator && depth == 0:
+			if part := strings.TrimSpace(value[start:index]); part != "" {
+				parts = append(parts, part)
+			}
+			start = index + 1
+		}
+	}
+	return parts
+}
+
+func isIdentifier(value string) bool {
+	return pythonIdentifierPattern.MatchString(value)
+}
+
+func isExportedIdentifier(value string) bool {
+	return isIdentifier(value) && ast.IsExported(value)
+}
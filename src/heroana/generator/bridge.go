@@ -0,0 +1,940 @@
+package generator
+
+import (
+	"fmt"
+	"go/format"
+	"log"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/EdmilsonRodrigues/melo-project/src/melo/files"
+)
+
+const bridgeHeader = "// Code generated by melo. DO NOT EDIT.\n\npackage main\n\n"
+
+// BridgeRuntime is the cgo source shared by every generated bridge. It owns
+// the main function of the shared library and the memory helpers used by the
+// Python runtime.
+const BridgeRuntime = bridgeHeader + `/*
+#include <stdint.h>
+#include <stdlib.h>
+
+typedef void (*melo_release_ref_cb)(uintptr_t);
+
+static melo_release_ref_cb melo_release_ref_fn;
+
+static inline void melo_store_release_ref(melo_release_ref_cb fn) { melo_release_ref_fn = fn; }
+
+static inline void melo_invoke_release_ref(uintptr_t ref) {
+	if (melo_release_ref_fn) {
+		melo_release_ref_fn(ref);
+	}
+}
+
+typedef void (*melo_io_cb)(uintptr_t ref, void* buffer, size_t size, size_t* count, char** failure);
+
+static melo_io_cb melo_read_fn;
+static melo_io_cb melo_write_fn;
+
+static inline void melo_store_io(melo_io_cb read, melo_io_cb write) {
+	melo_read_fn = read;
+	melo_write_fn = write;
+}
+
+static inline void melo_invoke_read(uintptr_t ref, void* buffer, size_t size, size_t* count, char** failure) {
+	melo_read_fn(ref, buffer, size, count, failure);
+}
+
+static inline void melo_invoke_write(uintptr_t ref, void* buffer, size_t size, size_t* count, char** failure) {
+	melo_write_fn(ref, buffer, size, count, failure);
+}
+*/
+import "C"
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"math/big"
+	"runtime"
+	"runtime/cgo"
+	"strconv"
+	"sync"
+	"unsafe"
+)
+
+func main() {}
+
+//export melo_free
+func melo_free(pointer unsafe.Pointer) {
+	C.free(pointer)
+}
+
+//export melo_release
+func melo_release(handle C.uintptr_t) {
+	cgo.Handle(handle).Delete()
+}
+
+//export melo_string
+func melo_string(value *C.char) *C.char {
+	return C.CString(C.GoString(value))
+}
+
+//export melo_set_release_ref
+func melo_set_release_ref(fn C.melo_release_ref_cb) {
+	C.melo_store_release_ref(fn)
+}
+
+// recoverFailure turns a panic of the Go code called by an export into the
+// error the export returns, rather than crashing the Python process.
+func recoverFailure(failure **C.char) {
+	if recovered := recover(); recovered != nil {
+		*failure = C.CString(fmt.Sprint(recovered))
+	}
+}
+
+// parseComplex reads a complex number formatted by the Python runtime.
+func parseComplex(text *C.char) complex128 {
+	value, _ := strconv.ParseComplex(C.GoString(text), 128)
+	return value
+}
+
+func formatComplex(value complex128, bitSize int) *C.char {
+	return C.CString(strconv.FormatComplex(value, 'g', -1, bitSize))
+}
+
+// Arbitrary precision numbers cross as decimal text, and nil pointers as NULL.
+
+func parseBigInt(text *C.char) *big.Int {
+	if text == nil {
+		return nil
+	}
+	value, _ := new(big.Int).SetString(C.GoString(text), 10)
+	return value
+}
+
+// parseBigFloat keeps at least as many bits as the decimal digits received.
+func parseBigFloat(text *C.char) *big.Float {
+	if text == nil {
+		return nil
+	}
+	digits := C.GoString(text)
+	value, _, _ := big.ParseFloat(digits, 10, max(64, uint(len(digits))*4), big.ToNearestEven)
+	return value
+}
+
+func parseBigRat(text *C.char) *big.Rat {
+	if text == nil {
+		return nil
+	}
+	value, _ := new(big.Rat).SetString(C.GoString(text))
+	return value
+}
+
+func formatBigInt(value *big.Int) *C.char {
+	if value == nil {
+		return nil
+	}
+	return C.CString(value.String())
+}
+
+func formatBigFloat(value *big.Float) *C.char {
+	if value == nil {
+		return nil
+	}
+	return C.CString(value.Text('g', -1))
+}
+
+func formatBigRat(value *big.Rat) *C.char {
+	if value == nil {
+		return nil
+	}
+	return C.CString(value.String())
+}
+
+// releaseRef tells the Python runtime that Go no longer holds a reference to
+// a Python object.
+func releaseRef(ref C.uintptr_t) {
+	C.melo_invoke_release_ref(ref)
+}
+
+// channelReceiver is the handle given to Python for a channel returned by Go.
+// Closing it cancels the context the producer was called with, and with it
+// the channels fed from Python. Producers taking no context cannot be told to
+// stop, so their channel is drained in the background instead, until they
+// close it: only producers that never close their channel keep running.
+type channelReceiver[T any] struct {
+	values <-chan T
+	cancel context.CancelFunc
+	drain  bool
+	fed    *feedFailure
+	once   sync.Once
+}
+
+func newReceiver[T any](values <-chan T, cancel context.CancelFunc, drain bool, fed *feedFailure) *channelReceiver[T] {
+	return &channelReceiver[T]{values: values, cancel: cancel, drain: drain, fed: fed}
+}
+
+// next receives the next value, or once the channel is closed, the exception
+// raised by an iterator feeding the producer, if any.
+func (receiver *channelReceiver[T]) next() (value T, ok bool, err error) {
+	value, ok = <-receiver.values
+	if !ok {
+		err = receiver.fed.get()
+	}
+	return
+}
+
+func (receiver *channelReceiver[T]) close() {
+	receiver.once.Do(func() {
+		receiver.cancel()
+		if receiver.drain {
+			go func() {
+				for range receiver.values {
+				}
+			}()
+		}
+	})
+}
+
+// feedFailure keeps the first exception raised by the Python iterators feeding
+// the channels of a call, so that the call fails instead of taking the input
+// cut short by the exception for the whole of it.
+type feedFailure struct {
+	mutex sync.Mutex
+	err   error
+}
+
+func (fed *feedFailure) set(failure *C.char) {
+	fed.mutex.Lock()
+	defer fed.mutex.Unlock()
+	if fed.err == nil {
+		fed.err = errors.New(C.GoString(failure))
+	}
+	C.free(unsafe.Pointer(failure))
+}
+
+func (fed *feedFailure) get() error {
+	fed.mutex.Lock()
+	defer fed.mutex.Unlock()
+	return fed.err
+}
+
+// sequencePuller is the handle given to Python for an iter.Seq returned by
+// Go. Python pulls one value at a time and stops the sequence when done.
+type sequencePuller[T any] struct {
+	mutex sync.Mutex
+	next  func() (T, bool)
+	stop  func()
+}
+
+func newSequencePuller[T any](sequence iter.Seq[T]) *sequencePuller[T] {
+	next, stop := iter.Pull(sequence)
+	return &sequencePuller[T]{next: next, stop: stop}
+}
+
+func (puller *sequencePuller[T]) pull() (T, bool) {
+	puller.mutex.Lock()
+	defer puller.mutex.Unlock()
+	return puller.next()
+}
+
+func (puller *sequencePuller[T]) close() {
+	puller.mutex.Lock()
+	defer puller.mutex.Unlock()
+	puller.stop()
+}
+
+// pairPuller is the sequencePuller of iter.Seq2.
+type pairPuller[K, V any] struct {
+	mutex sync.Mutex
+	next  func() (K, V, bool)
+	stop  func()
+}
+
+func newPairPuller[K, V any](sequence iter.Seq2[K, V]) *pairPuller[K, V] {
+	next, stop := iter.Pull2(sequence)
+	return &pairPuller[K, V]{next: next, stop: stop}
+}
+
+func (puller *pairPuller[K, V]) pull() (K, V, bool) {
+	puller.mutex.Lock()
+	defer puller.mutex.Unlock()
+	return puller.next()
+}
+
+func (puller *pairPuller[K, V]) close() {
+	puller.mutex.Lock()
+	defer puller.mutex.Unlock()
+	puller.stop()
+}
+
+//export melo_set_io
+func melo_set_io(read C.melo_io_cb, write C.melo_io_cb) {
+	C.melo_store_io(read, write)
+}
+
+// pythonReader is the io.Reader reading from a Python binary file-like object.
+type pythonReader struct {
+	ref C.uintptr_t
+}
+
+func newPythonReader(ref C.uintptr_t) *pythonReader {
+	reader := &pythonReader{ref: ref}
+	runtime.SetFinalizer(reader, func(reader *pythonReader) { releaseRef(reader.ref) })
+	return reader
+}
+
+func (reader *pythonReader) Read(buffer []byte) (int, error) {
+	if len(buffer) == 0 {
+		return 0, nil
+	}
+	var count C.size_t
+	var failure *C.char
+	C.melo_invoke_read(reader.ref, unsafe.Pointer(&buffer[0]), C.size_t(len(buffer)), &count, &failure)
+	if failure != nil {
+		defer C.free(unsafe.Pointer(failure))
+		return 0, errors.New(C.GoString(failure))
+	}
+	if count == 0 {
+		return 0, io.EOF
+	}
+	return int(count), nil
+}
+
+// pythonWriter is the io.Writer writing to a Python binary file-like object.
+type pythonWriter struct {
+	ref C.uintptr_t
+}
+
+func newPythonWriter(ref C.uintptr_t) *pythonWriter {
+	writer := &pythonWriter{ref: ref}
+	runtime.SetFinalizer(writer, func(writer *pythonWriter) { releaseRef(writer.ref) })
+	return writer
+}
+
+func (writer *pythonWriter) Write(buffer []byte) (int, error) {
+	if len(buffer) == 0 {
+		return 0, nil
+	}
+	var count C.size_t
+	var failure *C.char
+	C.melo_invoke_write(writer.ref, unsafe.Pointer(&buffer[0]), C.size_t(len(buffer)), &count, &failure)
+	if failure != nil {
+		defer C.free(unsafe.Pointer(failure))
+		return int(count), errors.New(C.GoString(failure))
+	}
+	if int(count) < len(buffer) {
+		return int(count), io.ErrShortWrite
+	}
+	return int(count), nil
+}
+
+// goReader is the handle given to Python for an io.Reader returned by Go.
+// Errors are kept until Python consumed the bytes read along with them.
+type goReader struct {
+	reader io.Reader
+	err    error
+}
+
+func newGoReader(reader io.Reader) *goReader {
+	return &goReader{reader: reader}
+}
+
+//export melo_reader_read
+func melo_reader_read(handle C.uintptr_t, buffer unsafe.Pointer, size C.size_t, count *C.size_t) (failure *C.char) {
+	defer recoverFailure(&failure)
+	reader := cgo.Handle(handle).Value().(*goReader)
+	for reader.err == nil {
+		n, err := reader.reader.Read(unsafe.Slice((*byte)(buffer), int(size)))
+		reader.err = err
+		if n > 0 {
+			*count = C.size_t(n)
+			return nil
+		}
+	}
+	*count = 0
+	if reader.err == io.EOF {
+		return nil
+	}
+	return C.CString(reader.err.Error())
+}
+
+//export melo_reader_close
+func melo_reader_close(handle C.uintptr_t) (failure *C.char) {
+	defer recoverFailure(&failure)
+	reader := cgo.Handle(handle).Value().(*goReader)
+	if closer, ok := reader.reader.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			return C.CString(err.Error())
+		}
+	}
+	return nil
+}
+`
+
+// exportFunction is a cgo exported function wrapping a single Go statement.
+// Results are written to out parameters and errors are returned as C strings.
+type exportFunction struct {
+	Symbol   string
+	Receiver string
+	// NilGuards are the selectors of embedded pointers of the receiver the
+	// call goes through, reported as errors when nil.
+	NilGuards    []string
+	Arguments    []bridgeArgument
+	Results      []bridgeType
+	ReturnsError bool
+	Invoke       func(arguments []string) string
+}
+
+// GenerateBridge renders the cgo source exposing the exported objects of a
+// package to its generated Python module.
+func GenerateBridge(exportedPackage files.ExportedPackage, objects ExportedObjects, dependencies ...Dependency) ([]byte, error) {
+	context := newGeneratorContext(exportedPackage, objects)
+	context.addDependencies(dependencies)
+	preamble := &strings.Builder{}
+	body := &strings.Builder{}
+
+	for _, exportedInterface := range context.objects.ExportedInterfaces {
+		if !context.proxyable(exportedInterface) {
+			log.Printf("Skipping interface %s: unsupported method types", exportedInterface.Name)
+			continue
+		}
+		context.writeInterfaceProxy(preamble, body, exportedInterface)
+	}
+
+	for _, exportedStruct := range context.objects.ExportedStructs {
+		context.writeStructBridge(body, exportedStruct)
+	}
+
+	for _, function := range context.objects.ExportedFunctions {
+		call, ok := context.resolveRoutine(function, context.symbol(function.Name))
+		if !ok {
+			log.Printf("Skipping function %s: unsupported types", function.Name)
+			continue
+		}
+		writeExportFunction(body, exportFunction{
+			Symbol:       call.Symbol,
+			Arguments:    call.Arguments,
+			Results:      call.Results,
+			ReturnsError: call.ReturnsError,
+			Invoke: func(arguments []string) string {
+				return fmt.Sprintf("%s.%s(%s)", context.alias, context.goName(function.Name), strings.Join(arguments, ", "))
+			},
+		})
+	}
+
+	for _, variable := range context.exportedVariables() {
+		context.writeVariableBridge(body, variable)
+	}
+
+	for _, helper := range context.helpers {
+		switch helper.kind {
+		case receiveChannelKind:
+			writeReceiverBridge(body, helper)
+		case feedChannelKind:
+			writeFeederBridge(preamble, body, helper)
+		case sequenceKind:
+			writeSequenceBridge(body, helper)
+		case variadicKind:
+			writeSliceBridge(body, helper)
+		case optionsKind:
+			writeOptionsBridge(body, helper, context.alias)
+		case handlerKind:
+			writeHandlerBridge(body, helper)
+		case functionKind:
+			writeFunctionBridge(preamble, body, helper)
+		}
+	}
+
+	source := &strings.Builder{}
+	source.WriteString(bridgeHeader)
+	fmt.Fprintf(source, "/*\n#include <stdbool.h>\n#include <stdint.h>\n#include <stdlib.h>\n%s*/\nimport \"C\"\n\n", preamble.String())
+	writeBridgeImports(source, body.String(), context)
+	source.WriteString(body.String())
+
+	formatted, err := format.Source([]byte(source.String()))
+	if err != nil {
+		return nil, fmt.Errorf("error formatting bridge for %s: %w", exportedPackage.GoPath, err)
+	}
+	return formatted, nil
+}
+
+var bridgeStandardImports = []string{"bytes", "context", "errors", "log", "math/big", "net/http", "runtime", "runtime/cgo", "strings", "time", "unsafe"}
+
+func writeBridgeImports(source *strings.Builder, body string, context *generatorContext) {
+	standardImports := []string{}
+	for _, standardImport := range bridgeStandardImports {
+		splittedImport := strings.Split(standardImport, "/")
+		if packageUsage(splittedImport[len(splittedImport)-1]).MatchString(body) {
+			standardImports = append(standardImports, standardImport)
+		}
+	}
+
+	source.WriteString("import (\n")
+	for _, standardImport := range standardImports {
+		fmt.Fprintf(source, "\t%q\n", standardImport)
+	}
+	packageImports := map[string]string{}
+	if strings.Contains(body, context.alias+".") {
+		packageImports[context.exportedPackage.GoPath] = context.alias
+	}
+	maps.Copy(packageImports, context.imports)
+	if len(packageImports) > 0 {
+		source.WriteString("\n")
+	}
+	for _, goPath := range slices.Sorted(maps.Keys(packageImports)) {
+		fmt.Fprintf(source, "\t%s %q\n", packageImports[goPath], goPath)
+	}
+	source.WriteString(")\n\n")
+}
+
+func packageUsage(name string) *regexp.Regexp {
+	return regexp.MustCompile(`(^|[^\w.])` + name + `\.`)
+}
+
+func writeExportFunction(body *strings.Builder, function exportFunction) {
+	parameters := []string{}
+	if function.Receiver != "" {
+		parameters = append(parameters, "self C.uintptr_t")
+	}
+	arguments := make([]string, 0, len(function.Arguments))
+	cancellable, contextual, receiving, feeding := false, false, false, false
+	for index, argument := range function.Arguments {
+		switch {
+		case argument.Type.kind == optionsKind:
+			parameters = append(parameters, optionParameterDeclarations(argument.Type, fmt.Sprintf("in%d", index))...)
+		case argument.Type.kind == variadicKind:
+			parameters = append(parameters, fmt.Sprintf("in%d %s", index, argument.Type.cType()), fmt.Sprintf("in%dLength C.size_t", index))
+		case argument.Type.kind == anonymousStructKind:
+			for field, parameter := range argument.Type.fieldParameters(fmt.Sprintf("in%d", index)) {
+				parameters = append(parameters, fmt.Sprintf("%s %s", parameter, argument.Type.fields[field].Type.cType()))
+			}
+		case !argument.Type.hidden():
+			parameters = append(parameters, fmt.Sprintf("in%d %s", index, argument.Type.cType()))
+		}
+		arguments = append(arguments, argument.Type.toGo(fmt.Sprintf("in%d", index)))
+		cancellable = cancellable || argument.Type.kind == contextKind || argument.Type.kind == feedChannelKind
+		contextual = contextual || argument.Type.kind == contextKind
+		feeding = feeding || argument.Type.kind == feedChannelKind
+	}
+	results := make([]string, 0, len(function.Results)+1)
+	for index, result := range function.Results {
+		if result.kind == anonymousStructKind {
+			for field, parameter := range result.fieldParameters(fmt.Sprintf("out%d", index)) {
+				parameters = append(parameters, fmt.Sprintf("%s *%s", parameter, result.fields[field].Type.cType()))
+			}
+		} else {
+			parameters = append(parameters, fmt.Sprintf("out%d *%s", index, result.cType()))
+		}
+		results = append(results, fmt.Sprintf("result%d", index))
+		receiving = receiving || result.kind == receiveChannelKind
+	}
+	if function.ReturnsError {
+		results = append(results, "err")
+	}
+
+	fmt.Fprintf(body, "//export %s\nfunc %s(%s) (failure *C.char) {\n", function.Symbol, function.Symbol, strings.Join(parameters, ", "))
+	body.WriteString("\tdefer recoverFailure(&failure)\n")
+	if function.Receiver != "" {
+		fmt.Fprintf(body, "\treceiver := cgo.Handle(self).Value().(*%s)\n", function.Receiver)
+	}
+	for _, selector := range function.NilGuards {
+		fmt.Fprintf(body, "\tif receiver.%s == nil {\n\t\treturn C.CString(%q)\n\t}\n", selector, "embedded field "+selector+" is nil")
+	}
+	// Channels returned to Python own the cancellation of the call, which is
+	// triggered when Python closes them. The channels of producers taking no
+	// context are drained once closed, so that they are not left blocked on
+	// their next send.
+	if cancellable {
+		body.WriteString("\tctx, cancel := context.WithCancel(context.Background())\n")
+		if !receiving {
+			body.WriteString("\tdefer cancel()\n")
+		}
+	} else if receiving {
+		body.WriteString("\tcancel := context.CancelFunc(func() {})\n")
+	}
+	if receiving {
+		fmt.Fprintf(body, "\tdrain := %t\n", !contextual)
+	}
+	// Exceptions of the fed iterators are reported by the call, or by the
+	// returned channels once closed when the call streams its results.
+	if feeding || receiving {
+		body.WriteString("\tfed := new(feedFailure)\n")
+	}
+	if len(results) == 0 {
+		fmt.Fprintf(body, "\t%s\n", function.Invoke(arguments))
+	} else {
+		fmt.Fprintf(body, "\t%s := %s\n", strings.Join(results, ", "), function.Invoke(arguments))
+	}
+	if function.ReturnsError {
+		body.WriteString("\tif err != nil {\n")
+		if receiving {
+			body.WriteString("\t\tcancel()\n")
+		}
+		body.WriteString("\t\treturn C.CString(err.Error())\n\t}\n")
+	}
+	if feeding && !receiving {
+		body.WriteString("\tif err := fed.get(); err != nil {\n\t\treturn C.CString(err.Error())\n\t}\n")
+	}
+	for index, result := range function.Results {
+		if result.kind != anonymousStructKind {
+			fmt.Fprintf(body, "\t*out%d = %s\n", index, result.toC(fmt.Sprintf("result%d", index)))
+			continue
+		}
+		for field, parameter := range result.fieldParameters(fmt.Sprintf("out%d", index)) {
+			fmt.Fprintf(body, "\t*%s = %s\n", parameter, result.fields[field].Type.toC(fmt.Sprintf("result%d.%s", index, result.fields[field].Name)))
+		}
+	}
+	body.WriteString("\treturn nil\n}\n\n")
+}
+
+func (context *generatorContext) writeStructBridge(body *strings.Builder, exportedStruct ExportedStruct) {
+	goType := context.alias + "." + context.goName(exportedStruct.Name)
+	handleType := bridgeType{kind: structPointerKind, goType: "*" + goType, name: exportedStruct.Name}
+
+	writeExportFunction(body, exportFunction{
+		Symbol:  context.symbol(exportedStruct.Name, "new"),
+		Results: []bridgeType{handleType},
+		Invoke: func([]string) string {
+			return fmt.Sprintf("new(%s)", goType)
+		},
+	})
+
+	for _, field := range context.exportedFields(exportedStruct) {
+		fieldType := field.Bridge
+		writeExportFunction(body, exportFunction{
+			Symbol:    context.symbol(exportedStruct.Name, "get", field.Name),
+			Receiver:  goType,
+			NilGuards: context.nilEmbeddings[exportedStruct.Name+"."+field.Name],
+			Results:   []bridgeType{fieldType},
+			Invoke: func([]string) string {
+				return "receiver." + field.Name
+			},
+		})
+		if field.ReadOnly {
+			continue
+		}
+		writeExportFunction(body, exportFunction{
+			Symbol:    context.symbol(exportedStruct.Name, "set", field.Name),
+			Receiver:  goType,
+			NilGuards: context.nilEmbeddings[exportedStruct.Name+"."+field.Name],
+			Arguments: []bridgeArgument{{Name: "value", Type: fieldType}},
+			Invoke: func(arguments []string) string {
+				return fmt.Sprintf("receiver.%s = %s", field.Name, arguments[0])
+			},
+		})
+	}
+
+	for _, method := range exportedStruct.Methods {
+		call, ok := context.resolveRoutine(method, context.symbol(exportedStruct.Name, method.Name))
+		if !ok {
+			log.Printf("Skipping method %s.%s: unsupported types", exportedStruct.Name, method.Name)
+			continue
+		}
+		writeExportFunction(body, exportFunction{
+			Symbol:       call.Symbol,
+			Receiver:     goType,
+			NilGuards:    context.nilEmbeddings[exportedStruct.Name+"."+method.Name],
+			Arguments:    call.Arguments,
+			Results:      call.Results,
+			ReturnsError: call.ReturnsError,
+			Invoke: func(arguments []string) string {
+				return fmt.Sprintf("receiver.%s(%s)", method.Name, strings.Join(arguments, ", "))
+			},
+		})
+	}
+}
+
+// writeReceiverBridge renders the functions Python uses to receive from and to
+// close the channels of one element type.
+func writeReceiverBridge(body *strings.Builder, channel bridgeType) {
+	receiverType := fmt.Sprintf("channelReceiver[%s]", channel.element.goType)
+	writeExportFunction(body, exportFunction{
+		Symbol:       channel.symbol + "_next",
+		Receiver:     receiverType,
+		Results:      []bridgeType{*channel.element, {kind: boolKind, goType: "bool"}},
+		ReturnsError: true,
+		Invoke: func([]string) string {
+			return "receiver.next()"
+		},
+	})
+	writeExportFunction(body, exportFunction{
+		Symbol:   channel.symbol + "_close",
+		Receiver: receiverType,
+		Invoke: func([]string) string {
+			return "receiver.close()"
+		},
+	})
+}
+
+// writeSequenceBridge renders the functions Python uses to pull from and to
+// stop the sequences of one element type.
+func writeSequenceBridge(body *strings.Builder, sequence bridgeType) {
+	writeExportFunction(body, exportFunction{
+		Symbol:   sequence.symbol + "_pull",
+		Receiver: sequence.goType,
+		Results:  sequence.pullResults(),
+		Invoke: func([]string) string {
+			return "receiver.pull()"
+		},
+	})
+	writeExportFunction(body, exportFunction{
+		Symbol:   sequence.symbol + "_stop",
+		Receiver: sequence.goType,
+		Invoke: func([]string) string {
+			return "receiver.close()"
+		},
+	})
+}
+
+// writeSliceBridge renders the conversion of a C array received from Python
+// into the Go slice passed to variadic functions.
+func writeSliceBridge(body *strings.Builder, variadic bridgeType) {
+	element := *variadic.element
+	fmt.Fprintf(body, "func %s(pointer *%s, length C.size_t) []%s {\n", variadic.symbol, element.cType(), element.goType)
+	fmt.Fprintf(body, "\tvalues := make([]%s, 0, int(length))\n", element.goType)
+	body.WriteString("\tfor _, value := range unsafe.Slice(pointer, int(length)) {\n")
+	fmt.Fprintf(body, "\t\tvalues = append(values, %s)\n", element.toGo("value"))
+	body.WriteString("\t}\n\treturn values\n}\n\n")
+}
+
+// writeOptionsBridge renders the function building the functional options
+// passed to a Go function from the keyword arguments set in Python.
+func writeOptionsBridge(body *strings.Builder, options bridgeType, alias string) {
+	fmt.Fprintf(body, "func %s(%s) []%s {\n", options.symbol, strings.Join(optionParameterDeclarations(options, "option"), ", "), options.goType)
+	fmt.Fprintf(body, "\toptions := []%s{}\n", options.goType)
+	for index, option := range options.options {
+		value := ""
+		if option.Value != nil {
+			value = option.Value.toGo(fmt.Sprintf("optionValue%d", index))
+		}
+		fmt.Fprintf(body, "\tif bool(optionSet%d) {\n\t\toptions = append(options, %s.%s(%s))\n\t}\n", index, alias, option.Function, value)
+	}
+	body.WriteString("\treturn options\n}\n\n")
+}
+
+// writeHandlerBridge renders the function serving one HTTP request with an
+// http.Handler returned by Go. Headers travel as "Name: value" lines and the
+// response is buffered before being handed to Python.
+func writeHandlerBridge(body *strings.Builder, handler bridgeType) {
+	response := handler.symbol + "_response"
+	fmt.Fprintf(body, "type %s struct {\n\theader http.Header\n\tstatus int\n\tbody bytes.Buffer\n}\n\n", response)
+	fmt.Fprintf(body, "func (response *%s) Header() http.Header {\n\treturn response.header\n}\n\n", response)
+	fmt.Fprintf(body, "func (response *%s) WriteHeader(status int) {\n\tif response.status == 0 {\n\t\tresponse.status = status\n\t}\n}\n\n", response)
+	fmt.Fprintf(body, "func (response *%s) Write(data []byte) (int, error) {\n\tresponse.WriteHeader(http.StatusOK)\n\treturn response.body.Write(data)\n}\n\n", response)
+
+	fmt.Fprintf(body, "//export %s_serve\n", handler.symbol)
+	fmt.Fprintf(body, "func %s_serve(self C.uintptr_t, method, target, headers *C.char, content *C.char, contentLength C.size_t, remote *C.char, status *C.longlong, responseHeaders **C.char, responseBody *unsafe.Pointer, responseLength *C.size_t) (failure *C.char) {\n", handler.symbol)
+	body.WriteString("\tdefer recoverFailure(&failure)\n")
+	body.WriteString("\thandler := cgo.Handle(self).Value().(http.Handler)\n")
+	body.WriteString("\trequest, err := http.NewRequest(C.GoString(method), C.GoString(target), bytes.NewReader(C.GoBytes(unsafe.Pointer(content), C.int(contentLength))))\n")
+	body.WriteString("\tif err != nil {\n\t\treturn C.CString(err.Error())\n\t}\n")
+	body.WriteString("\tfor _, line := range strings.Split(C.GoString(headers), \"\\n\") {\n")
+	body.WriteString("\t\tif name, value, ok := strings.Cut(line, \": \"); ok {\n\t\t\trequest.Header.Add(name, value)\n\t\t}\n\t}\n")
+	body.WriteString("\trequest.Host = request.Header.Get(\"Host\")\n")
+	body.WriteString("\trequest.RequestURI = C.GoString(target)\n")
+	body.WriteString("\trequest.RemoteAddr = C.GoString(remote)\n")
+	body.WriteString("\trequest.ContentLength = int64(contentLength)\n")
+	fmt.Fprintf(body, "\trecorder := &%s{header: http.Header{}}\n", response)
+	body.WriteString("\thandler.ServeHTTP(recorder, request)\n")
+	body.WriteString("\trecorder.WriteHeader(http.StatusOK)\n")
+	body.WriteString("\tlines := []string{}\n")
+	body.WriteString("\tfor name, values := range recorder.header {\n\t\tfor _, value := range values {\n\t\t\tlines = append(lines, name+\": \"+value)\n\t\t}\n\t}\n")
+	body.WriteString("\t*status = C.longlong(recorder.status)\n")
+	body.WriteString("\t*responseHeaders = C.CString(strings.Join(lines, \"\\n\"))\n")
+	body.WriteString("\t*responseBody = C.CBytes(recorder.body.Bytes())\n")
+	body.WriteString("\t*responseLength = C.size_t(recorder.body.Len())\n")
+	body.WriteString("\treturn nil\n}\n\n")
+}
+
+func optionParameterDeclarations(options bridgeType, expression string) []string {
+	declarations := []string{}
+	for index, option := range options.options {
+		declarations = append(declarations, fmt.Sprintf("%sSet%d C.bool", expression, index))
+		if option.Value != nil {
+			declarations = append(declarations, fmt.Sprintf("%sValue%d %s", expression, index, option.Value.cType()))
+		}
+	}
+	return declarations
+}
+
+// writeFeederBridge renders the function turning a Python iterator into a Go
+// channel. The iterator is pulled through a callback from a goroutine that
+// stops when the iterator is exhausted or when the call is cancelled.
+func writeFeederBridge(preamble, body *strings.Builder, channel bridgeType) {
+	element := *channel.element
+	pull := bridgeCall{
+		Symbol:  channel.symbol,
+		Results: []bridgeType{element, {kind: boolKind, goType: "bool"}},
+	}
+	writeCallbackDeclaration(preamble, pull)
+
+	fmt.Fprintf(body, "//export %s_register\nfunc %s_register(fn C.%s_cb) {\n\tC.%s_store(fn)\n}\n\n", channel.symbol, channel.symbol, channel.symbol, channel.symbol)
+
+	fmt.Fprintf(body, "func %s_feed(ctx context.Context, fed *feedFailure, ref C.uintptr_t) chan %s {\n", channel.symbol, element.goType)
+	fmt.Fprintf(body, "\tvalues := make(chan %s)\n", element.goType)
+	body.WriteString("\tgo func() {\n\t\tdefer close(values)\n\t\tdefer releaseRef(ref)\n\t\tfor {\n")
+	fmt.Fprintf(body, "\t\t\tvar out0 %s\n\t\t\tvar out1 C.bool\n\t\t\tvar failure *C.char\n", element.cType())
+	fmt.Fprintf(body, "\t\t\tC.%s_invoke(ref, &out0, &out1, &failure)\n", channel.symbol)
+	body.WriteString("\t\t\tif failure != nil {\n\t\t\t\tfed.set(failure)\n\t\t\t\treturn\n\t\t\t}\n")
+	body.WriteString("\t\t\tif !bool(out1) {\n\t\t\t\treturn\n\t\t\t}\n")
+	fmt.Fprintf(body, "\t\t\tvalue := %s\n", element.toGo("out0"))
+	if element.textual() {
+		body.WriteString("\t\t\tC.free(unsafe.Pointer(out0))\n")
+	}
+	body.WriteString("\t\t\tselect {\n\t\t\tcase values <- value:\n\t\t\tcase <-ctx.Done():\n\t\t\t\treturn\n\t\t\t}\n")
+	body.WriteString("\t\t}\n\t}()\n\treturn values\n}\n\n")
+}
+
+// proxyable reports whether every method of an interface can be called back
+// into Python, which is required for the proxy to implement the interface.
+func (context *generatorContext) proxyable(exportedInterface ExportedInterface) bool {
+	for _, method := range exportedInterface.Methods {
+		for _, argument := range method.Arguments {
+			if !context.resolveType(argument.Type).storable() {
+				return false
+			}
+		}
+		returnTypes := method.ReturnTypes
+		if len(returnTypes) > 0 && returnTypes[len(returnTypes)-1] == "error" {
+			returnTypes = returnTypes[:len(returnTypes)-1]
+		}
+		for _, returnType := range returnTypes {
+			if !context.resolveType(returnType).storable() {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// writeInterfaceProxy renders a Go type implementing the interface by calling
+// back into the Python object registered under the proxy reference.
+func (context *generatorContext) writeInterfaceProxy(preamble, body *strings.Builder, exportedInterface ExportedInterface) {
+	proxyName := proxyTypeName(context.symbol(exportedInterface.Name))
+
+	fmt.Fprintf(body, "type %s struct {\n\tref C.uintptr_t\n}\n\n", proxyName)
+	fmt.Fprintf(body, "func %s(ref C.uintptr_t) *%s {\n", proxyConstructorName(context.symbol(exportedInterface.Name)), proxyName)
+	fmt.Fprintf(body, "\tproxy := &%s{ref: ref}\n", proxyName)
+	fmt.Fprintf(body, "\truntime.SetFinalizer(proxy, func(proxy *%s) { releaseRef(proxy.ref) })\n", proxyName)
+	body.WriteString("\treturn proxy\n}\n\n")
+
+	for _, method := range exportedInterface.Methods {
+		symbol := context.symbol(exportedInterface.Name, method.Name)
+		call, _ := context.resolveRoutine(method, symbol)
+		writeCallbackDeclaration(preamble, call)
+		fmt.Fprintf(body, "//export %s_register\nfunc %s_register(fn C.%s_cb) {\n\tC.%s_store(fn)\n}\n\n", symbol, symbol, symbol, symbol)
+		writeProxyMethod(body, proxyName, method.Name, call)
+	}
+}
+
+// writeProxyMethod renders a method of a proxy calling back into Python
+// through the callback registered for the call symbol.
+func writeProxyMethod(body *strings.Builder, proxyName, methodName string, call bridgeCall) {
+	parameters := make([]string, 0, len(call.Arguments))
+	invokeArguments := []string{"proxy.ref"}
+	conversions := &strings.Builder{}
+	for index, argument := range call.Arguments {
+		parameters = append(parameters, fmt.Sprintf("argument%d %s", index, argument.Type.goType))
+		fmt.Fprintf(conversions, "\tin%d := %s\n", index, argument.Type.toC(fmt.Sprintf("argument%d", index)))
+		if argument.Type.textual() {
+			fmt.Fprintf(conversions, "\tdefer C.free(unsafe.Pointer(in%d))\n", index)
+		}
+		invokeArguments = append(invokeArguments, fmt.Sprintf("in%d", index))
+	}
+	results := make([]string, 0, len(call.Results)+1)
+	for index, result := range call.Results {
+		results = append(results, fmt.Sprintf("result%d %s", index, result.goType))
+		fmt.Fprintf(conversions, "\tvar out%d %s\n", index, result.cType())
+		invokeArguments = append(invokeArguments, fmt.Sprintf("&out%d", index))
+	}
+	if call.ReturnsError {
+		results = append(results, "err error")
+	}
+	invokeArguments = append(invokeArguments, "&failure")
+
+	fmt.Fprintf(body, "func (proxy *%s) %s(%s) (%s) {\n", proxyName, methodName, strings.Join(parameters, ", "), strings.Join(results, ", "))
+	body.WriteString(conversions.String())
+	body.WriteString("\tvar failure *C.char\n")
+	fmt.Fprintf(body, "\tC.%s_invoke(%s)\n", call.Symbol, strings.Join(invokeArguments, ", "))
+	body.WriteString("\tif failure != nil {\n\t\tdefer C.free(unsafe.Pointer(failure))\n")
+	if call.ReturnsError {
+		body.WriteString("\t\terr = errors.New(C.GoString(failure))\n\t\treturn\n\t}\n")
+	} else {
+		// Go may call the proxy from any goroutine, out of reach of the
+		// recoverFailure of the export, so the exception cannot be raised
+		// as a panic: it is logged and the method returns zero values.
+		fmt.Fprintf(body, "\t\tlog.Printf(%q, C.GoString(failure))\n\t\treturn\n\t}\n", call.Symbol+" raised in Python, returning zero values: %s")
+	}
+	for index, result := range call.Results {
+		if result.textual() {
+			fmt.Fprintf(body, "\tdefer C.free(unsafe.Pointer(out%d))\n", index)
+		}
+		fmt.Fprintf(body, "\tresult%d = %s\n", index, result.toGo(fmt.Sprintf("out%d", index)))
+	}
+	body.WriteString("\treturn\n}\n\n")
+}
+
+// writeFunctionBridge renders the two directions of a func type: a proxy
+// calling back into a Python callable, and an export calling a Go function
+// held by a handle.
+func writeFunctionBridge(preamble, body *strings.Builder, function bridgeType) {
+	call := *function.signature
+	call.Symbol = function.symbol
+	proxyName := proxyTypeName(function.symbol)
+	writeCallbackDeclaration(preamble, call)
+
+	fmt.Fprintf(body, "//export %s_register\nfunc %s_register(fn C.%s_cb) {\n\tC.%s_store(fn)\n}\n\n", function.symbol, function.symbol, function.symbol, function.symbol)
+	fmt.Fprintf(body, "type %s struct {\n\tref C.uintptr_t\n}\n\n", proxyName)
+	fmt.Fprintf(body, "func %s(ref C.uintptr_t) %s {\n", proxyConstructorName(function.symbol), function.goType)
+	fmt.Fprintf(body, "\tproxy := &%s{ref: ref}\n", proxyName)
+	fmt.Fprintf(body, "\truntime.SetFinalizer(proxy, func(proxy *%s) { releaseRef(proxy.ref) })\n", proxyName)
+	body.WriteString("\treturn proxy.invoke\n}\n\n")
+	writeProxyMethod(body, proxyName, "invoke", call)
+
+	writeExportFunction(body, exportFunction{
+		Symbol:       function.symbol + "_call",
+		Receiver:     function.goType,
+		Arguments:    call.Arguments,
+		Results:      call.Results,
+		ReturnsError: call.ReturnsError,
+		Invoke: func(arguments []string) string {
+			return fmt.Sprintf("(*receiver)(%s)", strings.Join(arguments, ", "))
+		},
+	})
+}
+
+// writeCallbackDeclaration declares the C function pointer registered by the
+// Python module for one interface method, with a setter and an invoker.
+func writeCallbackDeclaration(preamble *strings.Builder, call bridgeCall) {
+	parameters := []string{"uintptr_t ref"}
+	arguments := []string{"ref"}
+	for index, argument := range call.Arguments {
+		parameters = append(parameters, fmt.Sprintf("%s in%d", argument.Type.cDeclaration(), index))
+		arguments = append(arguments, fmt.Sprintf("in%d", index))
+	}
+	for index, result := range call.Results {
+		parameters = append(parameters, fmt.Sprintf("%s* out%d", result.cDeclaration(), index))
+		arguments = append(arguments, fmt.Sprintf("out%d", index))
+	}
+	parameters = append(parameters, "char** failure")
+	arguments = append(arguments, "failure")
+
+	fmt.Fprintf(preamble, "\ntypedef void (*%s_cb)(%s);\n\n", call.Symbol, strings.Join(parameters, ", "))
+	fmt.Fprintf(preamble, "static %s_cb %s_fn;\n\n", call.Symbol, call.Symbol)
+	fmt.Fprintf(preamble, "static inline void %s_store(%s_cb fn) { %s_fn = fn; }\n\n", call.Symbol, call.Symbol, call.Symbol)
+	fmt.Fprintf(preamble, "static inline void %s_invoke(%s) { %s_fn(%s); }\n", call.Symbol, strings.Join(parameters, ", "), call.Symbol, strings.Join(arguments, ", "))
+}
+
+func proxyTypeName(symbol string) string {
+	return symbol + "_proxy"
+}
+
+func proxyConstructorName(symbol string) string {
+	return "new_" + proxyTypeName(symbol)
+}
@@ -0,0 +1,789 @@
+package generator_test
+
+import (
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	"github.com/EdmilsonRodrigues/melo-project/src/melo/files"
+	"github.com/EdmilsonRodrigues/melo-project/src/melo/generator"
+)
+
+var greeterPackage = files.ExportedPackage{
+	GoPath:     "example.com/greet",
+	PythonPath: "mypackage.greet",
+}
+
+var greeterObjects = generator.ExportedObjects{
+	ExportedStructs: []generator.ExportedStruct{
+		{
+			Name: "Person",
+			Fields: []generator.ExportedField{
+				{Name: "Name", Type: "string"},
+				{Name: "age", Type: "int"},
+			},
+			Methods: []generator.ExportedRoutine{
+				{
+					Name:        "Describe",
+					Arguments:   []generator.ExportedArgument{{Name: "prefix", Type: "string"}},
+					ReturnTypes: []string{"string"},
+				},
+			},
+		},
+	},
+	ExportedInterfaces: []generator.ExportedInterface{
+		{
+			Name: "Greeter",
+			Methods: []generator.ExportedRoutine{
+				{
+					Name:        "SayHello",
+					Arguments:   []generator.ExportedArgument{{Name: "name", Type: "string"}},
+					ReturnTypes: []string{"string"},
+				},
+				{
+					Name:        "Count",
+					Arguments:   []generator.ExportedArgument{{Name: "n", Type: "int"}},
+					ReturnTypes: []string{"int", "error"},
+				},
+			},
+			Doc: "Greeter greets people.",
+		},
+		{
+			Name: "Unsupported",
+			Methods: []generator.ExportedRoutine{
+				{
+					Name:        "Channel",
+					ReturnTypes: []string{"chan int"},
+				},
+			},
+		},
+	},
+	ExportedFunctions: []generator.ExportedRoutine{
+		{
+			Name: "Greet",
+			Arguments: []generator.ExportedArgument{
+				{Name: "greeter", Type: "example.com/greet.Greeter"},
+				{Name: "person", Type: "*example.com/greet.Person"},
+			},
+			ReturnTypes: []string{"string", "error"},
+			Doc:         "Greet greets a person.",
+		},
+		{
+			Name: "GreetAll",
+			Arguments: []generator.ExportedArgument{
+				{Name: "greeting", Type: "string"},
+				{Name: "people", Type: "[]*example.com/greet.Person", Variadic: true},
+			},
+			ReturnTypes: []string{"string"},
+		},
+		{
+			Name:      "UseUnsupported",
+			Arguments: []generator.ExportedArgument{{Name: "value", Type: "example.com/greet.Unsupported"}},
+		},
+	},
+}
+
+var channelObjects = generator.ExportedObjects{
+	ExportedFunctions: []generator.ExportedRoutine{
+		{
+			Name: "Count",
+			Arguments: []generator.ExportedArgument{
+				{Name: "ctx", Type: "context.Context"},
+				{Name: "n", Type: "int"},
+			},
+			ReturnTypes: []string{"<-chan int"},
+		},
+		{
+			Name:        "Join",
+			Arguments:   []generator.ExportedArgument{{Name: "names", Type: "<-chan string"}},
+			ReturnTypes: []string{"string"},
+		},
+		{
+			Name:      "Produce",
+			Arguments: []generator.ExportedArgument{{Name: "values", Type: "chan<- int"}},
+		},
+		{
+			Name:        "Letters",
+			Arguments:   []generator.ExportedArgument{{Name: "n", Type: "int"}},
+			ReturnTypes: []string{"<-chan string"},
+		},
+	},
+}
+
+var sequenceObjects = generator.ExportedObjects{
+	ExportedFunctions: []generator.ExportedRoutine{
+		{
+			Name:        "Numbers",
+			Arguments:   []generator.ExportedArgument{{Name: "n", Type: "int"}},
+			ReturnTypes: []string{"iter.Seq[int]"},
+		},
+		{
+			Name:        "Pairs",
+			ReturnTypes: []string{"iter.Seq2[string, float64]", "error"},
+		},
+	},
+}
+
+var optionObjects = generator.ExportedObjects{
+	ExportedStructs: []generator.ExportedStruct{{Name: "Client"}},
+	ExportedFunctions: []generator.ExportedRoutine{
+		{
+			Name: "Connect",
+			Arguments: []generator.ExportedArgument{
+				{Name: "addr", Type: "string"},
+				{Name: "opts", Type: "[]example.com/greet.Option", Variadic: true},
+			},
+			ReturnTypes: []string{"*example.com/greet.Client"},
+		},
+		{
+			Name:        "WithTimeout",
+			Arguments:   []generator.ExportedArgument{{Name: "timeout", Type: "time.Duration"}},
+			ReturnTypes: []string{"example.com/greet.Option"},
+		},
+		{
+			Name:        "WithMaxRetries",
+			Arguments:   []generator.ExportedArgument{{Name: "retries", Type: "int"}},
+			ReturnTypes: []string{"example.com/greet.Option"},
+		},
+		{
+			Name:        "WithDebug",
+			ReturnTypes: []string{"example.com/greet.Option"},
+		},
+	},
+}
+
+var streamObjects = generator.ExportedObjects{
+	ExportedFunctions: []generator.ExportedRoutine{
+		{
+			Name: "Copy",
+			Arguments: []generator.ExportedArgument{
+				{Name: "dst", Type: "io.Writer"},
+				{Name: "src", Type: "io.Reader"},
+			},
+			ReturnTypes: []string{"int64", "error"},
+		},
+		{
+			Name:        "Open",
+			Arguments:   []generator.ExportedArgument{{Name: "path", Type: "string"}},
+			ReturnTypes: []string{"io.ReadCloser", "error"},
+		},
+		{
+			Name:      "Consume",
+			Arguments: []generator.ExportedArgument{{Name: "body", Type: "io.ReadCloser"}},
+		},
+	},
+}
+
+var handlerObjects = generator.ExportedObjects{
+	ExportedFunctions: []generator.ExportedRoutine{
+		{
+			Name:        "App",
+			ReturnTypes: []string{"net/http.Handler"},
+			Directives:  []string{"asgi"},
+		},
+		{
+			Name:        "Plain",
+			ReturnTypes: []string{"net/http.Handler"},
+		},
+	},
+}
+
+var genericObjects = generator.ExportedObjects{
+	ExportedStructs: []generator.ExportedStruct{
+		{
+			Name:   "Box",
+			Fields: []generator.ExportedField{{Name: "Value", Type: "T"}},
+			Methods: []generator.ExportedRoutine{
+				{Name: "Get", ReturnTypes: []string{"U"}, TypeParameters: []string{"U"}},
+			},
+			Directives:     []string{"instantiate Box[int] Box[*Person]"},
+			TypeParameters: []string{"T"},
+		},
+		{Name: "Person"},
+	},
+	ExportedFunctions: []generator.ExportedRoutine{
+		{
+			Name:           "Identity",
+			Arguments:      []generator.ExportedArgument{{Name: "value", Type: "T"}},
+			ReturnTypes:    []string{"T"},
+			Directives:     []string{"instantiate Identity[int] Identity[string]"},
+			TypeParameters: []string{"T"},
+		},
+		{
+			Name:        "NewIntBox",
+			ReturnTypes: []string{"*example.com/greet.Box[int]"},
+		},
+		{
+			Name:           "Unlisted",
+			Arguments:      []generator.ExportedArgument{{Name: "value", Type: "T"}},
+			TypeParameters: []string{"T"},
+		},
+	},
+}
+
+var embeddingObjects = generator.ExportedObjects{
+	ExportedStructs: []generator.ExportedStruct{
+		{
+			Name:    "Base",
+			Fields:  []generator.ExportedField{{Name: "ID", Type: "int"}, {Name: "Kind", Type: "string"}},
+			Methods: []generator.ExportedRoutine{{Name: "Describe", ReturnTypes: []string{"string"}}},
+		},
+		{
+			Name:   "Audit",
+			Fields: []generator.ExportedField{{Name: "Kind", Type: "string"}, {Name: "Actor", Type: "string"}},
+		},
+		{
+			Name: "Document",
+			Fields: []generator.ExportedField{
+				{Name: "Base", Type: "example.com/greet.Base", Embedded: true},
+				{Name: "Audit", Type: "*example.com/greet.Audit", Embedded: true},
+				{Name: "Title", Type: "string"},
+			},
+		},
+	},
+}
+
+var taggedObjects = generator.ExportedObjects{
+	ExportedStructs: []generator.ExportedStruct{
+		{
+			Name: "Account",
+			Fields: []generator.ExportedField{
+				{Name: "UserID", Type: "int", Tags: map[string]string{"json": "user_id,omitempty"}},
+				{Name: "Email", Type: "string", Tags: map[string]string{"json": "email", "melo": "name=mail"}},
+				{Name: "Created", Type: "string", Tags: map[string]string{"melo": "readonly"}},
+				{Name: "Password", Type: "string", Tags: map[string]string{"json": "-"}},
+				{Name: "Secret", Type: "string", Tags: map[string]string{"melo": "skip"}},
+			},
+		},
+	},
+}
+
+var variableObjects = generator.ExportedObjects{
+	ExportedVariables: []generator.ExportedVariable{
+		{Name: "Greeting", Type: "string", Value: "hello"},
+		{Name: "Version", Type: "int", Directives: []string{"readonly"}},
+		{Name: "Hooks", Type: "[]func()"},
+	},
+}
+
+var remotePackage = files.ExportedPackage{
+	GoPath:     "example.com/other",
+	PythonPath: "mypackage.other",
+}
+
+var remoteDependencies = []generator.Dependency{
+	{
+		Package: remotePackage,
+		Objects: generator.ExportedObjects{
+			ExportedStructs: []generator.ExportedStruct{{Name: "Remote", Fields: []generator.ExportedField{{Name: "Host", Type: "string"}}}},
+			ExportedTypes:   []generator.ExportedType{{Name: "Level", Type: "int"}},
+		},
+	},
+	{
+		Package: files.ExportedPackage{GoPath: "example.com/v2/greet", PythonPath: "mypackage.greet2"},
+		Objects: generator.ExportedObjects{
+			ExportedStructs: []generator.ExportedStruct{{Name: "Legacy"}},
+		},
+	},
+}
+
+var aliasObjects = generator.ExportedObjects{
+	ExportedAliases: []generator.ExportedAlias{
+		{Name: "Person", Type: "example.com/greet.User"},
+		{Name: "RemoteAlias", Type: "example.com/other.Remote"},
+	},
+	ExportedStructs: []generator.ExportedStruct{{Name: "User", Fields: []generator.ExportedField{{Name: "Name", Type: "string"}}}},
+	ExportedFunctions: []generator.ExportedRoutine{
+		{Name: "Connect", Arguments: []generator.ExportedArgument{{Name: "remote", Type: "*example.com/other.Remote"}}, ReturnTypes: []string{"example.com/other.Level"}},
+		{Name: "Rename", Arguments: []generator.ExportedArgument{{Name: "user", Type: "example.com/greet.Person"}}},
+		{Name: "Upgrade", Arguments: []generator.ExportedArgument{{Name: "legacy", Type: "example.com/v2/greet.Legacy"}}},
+		{Name: "Lookup", ReturnTypes: []string{"example.com/hidden.Thing"}},
+	},
+}
+
+func TestGenerateBridge(t *testing.T) {
+	bridge, err := generator.GenerateBridge(greeterPackage, greeterObjects)
+	if err != nil {
+		t.Fatalf("GenerateBridge should not return error, got %v", err)
+	}
+	source := string(bridge)
+
+	t.Run("should render valid Go source", func(t *testing.T) {
+		if _, err := parser.ParseFile(token.NewFileSet(), "bridge.go", bridge, parser.AllErrors); err != nil {
+			t.Errorf("GenerateBridge should render valid Go source, got %v", err)
+		}
+	})
+
+	t.Run("should render a proxy implementing the interface", func(t *testing.T) {
+		for _, expected := range []string{
+			"type melo_mypackage_greet_Greeter_proxy struct {",
+			"func (proxy *melo_mypackage_greet_Greeter_proxy) SayHello(argument0 string) (result0 string) {",
+			"func (proxy *melo_mypackage_greet_Greeter_proxy) Count(argument0 int) (result0 int, err error) {",
+			"//export melo_mypackage_greet_Greeter_SayHello_register",
+			"typedef void (*melo_mypackage_greet_Greeter_Count_cb)(uintptr_t ref, long long in0, long long* out0, char** failure);",
+			"runtime.SetFinalizer(proxy, func(proxy *melo_mypackage_greet_Greeter_proxy) { releaseRef(proxy.ref) })",
+			"err = errors.New(C.GoString(failure))",
+		} {
+			if !strings.Contains(source, expected) {
+				t.Errorf("GenerateBridge should contain %q, got\n%s", expected, source)
+			}
+		}
+	})
+
+	t.Run("should log exceptions of methods without error result instead of panicking", func(t *testing.T) {
+		expected := "\t\tlog.Printf(\"melo_mypackage_greet_Greeter_SayHello raised in Python, returning zero values: %s\", C.GoString(failure))\n\t\treturn\n"
+		if !strings.Contains(source, expected) {
+			t.Errorf("GenerateBridge should contain %q, got\n%s", expected, source)
+		}
+		if strings.Contains(source, "panic(") {
+			t.Errorf("GenerateBridge should not panic in proxies, got\n%s", source)
+		}
+	})
+
+	t.Run("should pass the proxy to functions accepting the interface", func(t *testing.T) {
+		expected := "greet.Greet(new_melo_mypackage_greet_Greeter_proxy(in0), cgo.Handle(in1).Value().(*greet.Person))"
+		if !strings.Contains(source, expected) {
+			t.Errorf("GenerateBridge should contain %q, got\n%s", expected, source)
+		}
+	})
+
+	t.Run("should return panics of the Go code as errors", func(t *testing.T) {
+		expected := "func melo_mypackage_greet_Greet(in0 C.uintptr_t, in1 C.uintptr_t, out0 **C.char) (failure *C.char) {\n\tdefer recoverFailure(&failure)\n"
+		if !strings.Contains(source, expected) {
+			t.Errorf("GenerateBridge should contain %q, got\n%s", expected, source)
+		}
+	})
+
+	t.Run("should convert variadic arguments from C arrays", func(t *testing.T) {
+		for _, expected := range []string{
+			"func melo_mypackage_greet_GreetAll(in0 *C.char, in1 *C.uintptr_t, in1Length C.size_t, out0 **C.char) (failure *C.char) {",
+			"result0 := greet.GreetAll(C.GoString(in0), melo_mypackage_greet_slice_ptrgreet_Person(in1, in1Length)...)",
+			"func melo_mypackage_greet_slice_ptrgreet_Person(pointer *C.uintptr_t, length C.size_t) []*greet.Person {",
+			"values = append(values, cgo.Handle(value).Value().(*greet.Person))",
+		} {
+			if !strings.Contains(source, expected) {
+				t.Errorf("GenerateBridge should contain %q, got\n%s", expected, source)
+			}
+		}
+	})
+
+	t.Run("should skip interfaces that cannot be proxied", func(t *testing.T) {
+		for _, unexpected := range []string{"Unsupported", "UseUnsupported", "get_age"} {
+			if strings.Contains(source, unexpected) {
+				t.Errorf("GenerateBridge should not contain %q, got\n%s", unexpected, source)
+			}
+		}
+	})
+}
+
+func TestGenerateBridgeChannels(t *testing.T) {
+	bridge, err := generator.GenerateBridge(greeterPackage, channelObjects)
+	if err != nil {
+		t.Fatalf("GenerateBridge should not return error, got %v", err)
+	}
+	source := string(bridge)
+
+	t.Run("should hand returned channels to Python with the call cancellation", func(t *testing.T) {
+		for _, expected := range []string{
+			"func melo_mypackage_greet_Count(in1 C.longlong, out0 *C.uintptr_t) (failure *C.char) {\n\tdefer recoverFailure(&failure)\n\tctx, cancel := context.WithCancel(context.Background())\n\tdrain := false\n\tfed := new(feedFailure)\n\tresult0 := greet.Count(ctx, int(in1))",
+			"*out0 = C.uintptr_t(cgo.NewHandle(newReceiver(result0, cancel, drain, fed)))",
+			"//export melo_mypackage_greet_receiver_int_next",
+			"//export melo_mypackage_greet_receiver_int_close",
+		} {
+			if !strings.Contains(source, expected) {
+				t.Errorf("GenerateBridge should contain %q, got\n%s", expected, source)
+			}
+		}
+	})
+
+	t.Run("should drain the channels of producers taking no context once closed", func(t *testing.T) {
+		expected := "\tcancel := context.CancelFunc(func() {})\n\tdrain := true\n\tfed := new(feedFailure)\n\tresult0 := greet.Letters(int(in0))\n"
+		if !strings.Contains(source, expected) {
+			t.Errorf("GenerateBridge should contain %q, got\n%s", expected, source)
+		}
+	})
+
+	t.Run("should feed channel arguments from a Python iterator", func(t *testing.T) {
+		for _, expected := range []string{
+			"result0 := greet.Join(melo_mypackage_greet_feeder_string_feed(ctx, fed, in0))",
+			"\tdefer cancel()\n",
+			"func melo_mypackage_greet_feeder_string_feed(ctx context.Context, fed *feedFailure, ref C.uintptr_t) chan string {",
+			"case <-ctx.Done():",
+			"//export melo_mypackage_greet_feeder_string_register",
+		} {
+			if !strings.Contains(source, expected) {
+				t.Errorf("GenerateBridge should contain %q, got\n%s", expected, source)
+			}
+		}
+	})
+
+	t.Run("should fail the call with the exception raised by a fed iterator", func(t *testing.T) {
+		for _, expected := range []string{
+			"\t\t\tif failure != nil {\n\t\t\t\tfed.set(failure)\n\t\t\t\treturn\n\t\t\t}\n",
+			"result0 := greet.Join(melo_mypackage_greet_feeder_string_feed(ctx, fed, in0))\n\tif err := fed.get(); err != nil {\n\t\treturn C.CString(err.Error())\n\t}\n",
+			"result0, result1, err := receiver.next()",
+		} {
+			if !strings.Contains(source, expected) {
+				t.Errorf("GenerateBridge should contain %q, got\n%s", expected, source)
+			}
+		}
+	})
+
+	t.Run("should feed send-only channel arguments from a Python iterator", func(t *testing.T) {
+		for _, expected := range []string{
+			"greet.Produce(melo_mypackage_greet_feeder_int_feed(ctx, fed, in0))",
+			"func melo_mypackage_greet_feeder_int_feed(ctx context.Context, fed *feedFailure, ref C.uintptr_t) chan int {",
+			"//export melo_mypackage_greet_feeder_int_register",
+		} {
+			if !strings.Contains(source, expected) {
+				t.Errorf("GenerateBridge should contain %q, got\n%s", expected, source)
+			}
+		}
+	})
+}
+
+func TestGenerateBridgeSequences(t *testing.T) {
+	bridge, err := generator.GenerateBridge(greeterPackage, sequenceObjects)
+	if err != nil {
+		t.Fatalf("GenerateBridge should not return error, got %v", err)
+	}
+	source := string(bridge)
+
+	for _, expected := range []string{
+		"*out0 = C.uintptr_t(cgo.NewHandle(newSequencePuller(result0)))",
+		"*out0 = C.uintptr_t(cgo.NewHandle(newPairPuller(result0)))",
+		"func melo_mypackage_greet_sequence_int_pull(self C.uintptr_t, out0 *C.longlong, out1 *C.bool) (failure *C.char) {\n\tdefer recoverFailure(&failure)\n\treceiver := cgo.Handle(self).Value().(*sequencePuller[int])",
+		"func melo_mypackage_greet_sequence_string_float64_pull(self C.uintptr_t, out0 **C.char, out1 *C.double, out2 *C.bool) (failure *C.char) {\n\tdefer recoverFailure(&failure)\n\treceiver := cgo.Handle(self).Value().(*pairPuller[string, float64])",
+		"//export melo_mypackage_greet_sequence_int_stop",
+	} {
+		if !strings.Contains(source, expected) {
+			t.Errorf("GenerateBridge should contain %q, got\n%s", expected, source)
+		}
+	}
+}
+
+func TestGenerateBridgeOptions(t *testing.T) {
+	bridge, err := generator.GenerateBridge(greeterPackage, optionObjects)
+	if err != nil {
+		t.Fatalf("GenerateBridge should not return error, got %v", err)
+	}
+	source := string(bridge)
+
+	for _, expected := range []string{
+		"func melo_mypackage_greet_Connect(in0 *C.char, in1Set0 C.bool, in1Value0 C.longlong, in1Set1 C.bool, in1Value1 C.longlong, in1Set2 C.bool, out0 *C.uintptr_t) (failure *C.char) {",
+		"result0 := greet.Connect(C.GoString(in0), melo_mypackage_greet_options_Option(in1Set0, in1Value0, in1Set1, in1Value1, in1Set2)...)",
+		"\tif bool(optionSet0) {\n\t\toptions = append(options, greet.WithTimeout(time.Duration(optionValue0)))\n\t}\n",
+		"options = append(options, greet.WithDebug())",
+		"\t\"time\"\n",
+	} {
+		if !strings.Contains(source, expected) {
+			t.Errorf("GenerateBridge should contain %q, got\n%s", expected, source)
+		}
+	}
+}
+
+func TestGenerateBridgeStreams(t *testing.T) {
+	bridge, err := generator.GenerateBridge(greeterPackage, streamObjects)
+	if err != nil {
+		t.Fatalf("GenerateBridge should not return error, got %v", err)
+	}
+	source := string(bridge)
+
+	for _, expected := range []string{
+		"result0, err := greet.Copy(newPythonWriter(in0), newPythonReader(in1))",
+		"*out0 = C.uintptr_t(cgo.NewHandle(newGoReader(result0)))",
+	} {
+		if !strings.Contains(source, expected) {
+			t.Errorf("GenerateBridge should contain %q, got\n%s", expected, source)
+		}
+	}
+	if strings.Contains(source, "Consume") {
+		t.Errorf("GenerateBridge should skip io.ReadCloser arguments, got\n%s", source)
+	}
+}
+
+func TestGenerateBridgeHandlers(t *testing.T) {
+	bridge, err := generator.GenerateBridge(greeterPackage, handlerObjects)
+	if err != nil {
+		t.Fatalf("GenerateBridge should not return error, got %v", err)
+	}
+	source := string(bridge)
+
+	for _, expected := range []string{
+		"*out0 = C.uintptr_t(cgo.NewHandle(result0))",
+		"//export melo_mypackage_greet_http_serve",
+		"handler := cgo.Handle(self).Value().(http.Handler)",
+		"handler.ServeHTTP(recorder, request)",
+		"\t\"net/http\"\n",
+	} {
+		if !strings.Contains(source, expected) {
+			t.Errorf("GenerateBridge should contain %q, got\n%s", expected, source)
+		}
+	}
+	if strings.Contains(source, "Plain") {
+		t.Errorf("GenerateBridge should skip handlers without the asgi directive, got\n%s", source)
+	}
+}
+
+func TestGenerateBridgeGenerics(t *testing.T) {
+	bridge, err := generator.GenerateBridge(greeterPackage, genericObjects)
+	if err != nil {
+		t.Fatalf("GenerateBridge should not return error, got %v", err)
+	}
+	source := string(bridge)
+
+	for _, expected := range []string{
+		"func melo_mypackage_greet_Identity_int(in0 C.longlong, out0 *C.longlong) (failure *C.char) {\n\tdefer recoverFailure(&failure)\n\tresult0 := greet.Identity[int](int(in0))",
+		"result0 := greet.Identity[string](C.GoString(in0))",
+		"result0 := new(greet.Box[int])",
+		"receiver := cgo.Handle(self).Value().(*greet.Box[*greet.Person])",
+		"func melo_mypackage_greet_Box_ptrPerson_Get(self C.uintptr_t, out0 *C.uintptr_t) (failure *C.char) {",
+		"result0 := greet.NewIntBox()",
+	} {
+		if !strings.Contains(source, expected) {
+			t.Errorf("GenerateBridge should contain %q, got\n%s", expected, source)
+		}
+	}
+	if strings.Contains(source, "Unlisted") {
+		t.Errorf("GenerateBridge should skip generics without instantiation, got\n%s", source)
+	}
+}
+
+func TestGenerateBridgeEmbedding(t *testing.T) {
+	bridge, err := generator.GenerateBridge(greeterPackage, embeddingObjects)
+	if err != nil {
+		t.Fatalf("GenerateBridge should not return error, got %v", err)
+	}
+	source := string(bridge)
+
+	for _, expected := range []string{
+		"func melo_mypackage_greet_Document_get_ID(self C.uintptr_t, out0 *C.longlong) (failure *C.char) {\n\tdefer recoverFailure(&failure)\n\treceiver := cgo.Handle(self).Value().(*greet.Document)\n\tresult0 := receiver.ID",
+		"//export melo_mypackage_greet_Document_get_Actor",
+		"//export melo_mypackage_greet_Document_Describe",
+		"//export melo_mypackage_greet_Document_get_Base",
+	} {
+		if !strings.Contains(source, expected) {
+			t.Errorf("GenerateBridge should contain %q, got\n%s", expected, source)
+		}
+	}
+	if strings.Contains(source, "Document_get_Kind") {
+		t.Errorf("GenerateBridge should not promote ambiguous fields, got\n%s", source)
+	}
+
+	t.Run("should report nil embedded pointers of promoted members", func(t *testing.T) {
+		guard := "receiver := cgo.Handle(self).Value().(*greet.Document)\n\tif receiver.Audit == nil {\n\t\treturn C.CString(\"embedded field Audit is nil\")\n\t}\n\tresult0 := receiver.Actor"
+		if !strings.Contains(source, guard) {
+			t.Errorf("GenerateBridge should contain %q, got\n%s", guard, source)
+		}
+		if strings.Count(source, "receiver.Audit == nil") != 2 {
+			t.Errorf("GenerateBridge should only check the getter and setter of Actor, got\n%s", source)
+		}
+	})
+}
+
+func TestGenerateBridgeTags(t *testing.T) {
+	bridge, err := generator.GenerateBridge(greeterPackage, taggedObjects)
+	if err != nil {
+		t.Fatalf("GenerateBridge should not return error, got %v", err)
+	}
+	source := string(bridge)
+
+	t.Run("should keep the Go field names in symbols", func(t *testing.T) {
+		expected := "func melo_mypackage_greet_Account_set_Email(self C.uintptr_t, in0 *C.char) (failure *C.char) {"
+		if !strings.Contains(source, expected) {
+			t.Errorf("GenerateBridge should contain %q, got\n%s", expected, source)
+		}
+	})
+
+	t.Run("should not export setters of readonly fields", func(t *testing.T) {
+		if !strings.Contains(source, "//export melo_mypackage_greet_Account_get_Created") {
+			t.Errorf("GenerateBridge should export the getter of Created, got\n%s", source)
+		}
+		if strings.Contains(source, "Account_set_Created") {
+			t.Errorf("GenerateBridge should not export the setter of Created, got\n%s", source)
+		}
+	})
+
+	t.Run("should not export skipped fields", func(t *testing.T) {
+		if strings.Contains(source, "Account_get_Secret") {
+			t.Errorf("GenerateBridge should not export Secret, got\n%s", source)
+		}
+	})
+}
+
+func TestGenerateBridgeVariables(t *testing.T) {
+	bridge, err := generator.GenerateBridge(greeterPackage, variableObjects)
+	if err != nil {
+		t.Fatalf("GenerateBridge should not return error, got %v", err)
+	}
+	source := string(bridge)
+
+	t.Run("should read and write the live variables", func(t *testing.T) {
+		for _, expected := range []string{
+			"func melo_mypackage_greet_get_Greeting(out0 **C.char) (failure *C.char) {\n\tdefer recoverFailure(&failure)\n\tresult0 := greet.Greeting\n",
+			"func melo_mypackage_greet_set_Greeting(in0 *C.char) (failure *C.char) {\n\tdefer recoverFailure(&failure)\n\tgreet.Greeting = C.GoString(in0)\n",
+			"//export melo_mypackage_greet_get_Version",
+		} {
+			if !strings.Contains(source, expected) {
+				t.Errorf("GenerateBridge should contain %q, got\n%s", expected, source)
+			}
+		}
+	})
+
+	t.Run("should not export setters of readonly variables", func(t *testing.T) {
+		if strings.Contains(source, "set_Version") {
+			t.Errorf("GenerateBridge should not export the setter of Version, got\n%s", source)
+		}
+	})
+
+	t.Run("should skip variables of unsupported types", func(t *testing.T) {
+		if strings.Contains(source, "Hooks") {
+			t.Errorf("GenerateBridge should not export Hooks, got\n%s", source)
+		}
+	})
+}
+
+func TestGenerateBridgeDependencies(t *testing.T) {
+	bridge, err := generator.GenerateBridge(greeterPackage, aliasObjects, remoteDependencies...)
+	if err != nil {
+		t.Fatalf("GenerateBridge should not return error, got %v", err)
+	}
+	source := string(bridge)
+
+	t.Run("should import the dependencies under distinct aliases", func(t *testing.T) {
+		expected := "\tgreet \"example.com/greet\"\n\tother \"example.com/other\"\n\tgreet2 \"example.com/v2/greet\"\n"
+		if !strings.Contains(source, expected) {
+			t.Errorf("GenerateBridge should contain %q, got\n%s", expected, source)
+		}
+	})
+
+	t.Run("should use the handles of types of other packages", func(t *testing.T) {
+		for _, expected := range []string{
+			"cgo.Handle(in0).Value().(*other.Remote)",
+			"*out0 = C.longlong(result0)",
+			"*cgo.Handle(in0).Value().(*greet2.Legacy)",
+		} {
+			if !strings.Contains(source, expected) {
+				t.Errorf("GenerateBridge should contain %q, got\n%s", expected, source)
+			}
+		}
+	})
+
+	t.Run("should resolve aliases to the type they name", func(t *testing.T) {
+		expected := "greet.Rename(*cgo.Handle(in0).Value().(*greet.User))"
+		if !strings.Contains(source, expected) {
+			t.Errorf("GenerateBridge should contain %q, got\n%s", expected, source)
+		}
+	})
+
+	t.Run("should skip types of packages which are not exported", func(t *testing.T) {
+		if strings.Contains(source, "Lookup") {
+			t.Errorf("GenerateBridge should not export Lookup, got\n%s", source)
+		}
+	})
+}
+
+var anonymousObjects = generator.ExportedObjects{
+	ExportedFunctions: []generator.ExportedRoutine{
+		{Name: "Configure", Arguments: []generator.ExportedArgument{{Name: "options", Type: "struct{Timeout int; Name string \"json:\\\"name\\\"\"}"}}, ReturnTypes: []string{"struct{Applied bool}", "error"}},
+		{Name: "Apply", Arguments: []generator.ExportedArgument{{Name: "value", Type: "int"}, {Name: "transform", Type: "func(int) string"}}, ReturnTypes: []string{"string"}},
+		{Name: "Deferred", ReturnTypes: []string{"func(name string) (int, error)"}},
+		{Name: "Spread", Arguments: []generator.ExportedArgument{{Name: "collect", Type: "func(...int)"}}},
+		{Name: "Hidden", Arguments: []generator.ExportedArgument{{Name: "options", Type: "struct{timeout int}"}}},
+	},
+}
+
+func TestGenerateBridgeAnonymousTypes(t *testing.T) {
+	bridge, err := generator.GenerateBridge(greeterPackage, anonymousObjects)
+	if err != nil {
+		t.Fatalf("GenerateBridge should not return error, got %v", err)
+	}
+	source := string(bridge)
+
+	t.Run("should pass the fields of anonymous structs one by one", func(t *testing.T) {
+		for _, expected := range []string{
+			"func melo_mypackage_greet_Configure(in0F0 C.longlong, in0F1 *C.char, out0F0 *C.bool) (failure *C.char) {",
+			"}{Timeout: int(in0F0), Name: C.GoString(in0F1)})",
+			"*out0F0 = C.bool(result0.Applied)",
+		} {
+			if !strings.Contains(source, expected) {
+				t.Errorf("GenerateBridge should contain %q, got\n%s", expected, source)
+			}
+		}
+	})
+
+	t.Run("should call Python callables through a proxy function", func(t *testing.T) {
+		for _, expected := range []string{
+			"result0 := greet.Apply(int(in0), new_melo_mypackage_greet_func_int_to_string_proxy(in1))",
+			"func new_melo_mypackage_greet_func_int_to_string_proxy(ref C.uintptr_t) func(int) string {",
+			"\treturn proxy.invoke\n",
+			"//export melo_mypackage_greet_func_int_to_string_register",
+		} {
+			if !strings.Contains(source, expected) {
+				t.Errorf("GenerateBridge should contain %q, got\n%s", expected, source)
+			}
+		}
+	})
+
+	t.Run("should call returned Go functions through their handle", func(t *testing.T) {
+		for _, expected := range []string{
+			"*out0 = C.uintptr_t(cgo.NewHandle(&result0))",
+			"receiver := cgo.Handle(self).Value().(*func(string) (int, error))\n\tresult0, err := (*receiver)(C.GoString(in0))",
+		} {
+			if !strings.Contains(source, expected) {
+				t.Errorf("GenerateBridge should contain %q, got\n%s", expected, source)
+			}
+		}
+	})
+
+	t.Run("should skip variadic functions and unexported fields", func(t *testing.T) {
+		for _, unexpected := range []string{"Spread", "Hidden"} {
+			if strings.Contains(source, unexpected) {
+				t.Errorf("GenerateBridge should not contain %q, got\n%s", unexpected, source)
+			}
+		}
+	})
+}
+
+var numberObjects = generator.ExportedObjects{
+	ExportedTypes: []generator.ExportedType{{Name: "Level", Type: "int8"}},
+	ExportedFunctions: []generator.ExportedRoutine{
+		{Name: "Rotate", Arguments: []generator.ExportedArgument{{Name: "point", Type: "complex64"}}, ReturnTypes: []string{"complex128"}},
+		{Name: "Factorial", Arguments: []generator.ExportedArgument{{Name: "n", Type: "uint16"}}, ReturnTypes: []string{"*math/big.Int"}},
+		{Name: "Scale", Arguments: []generator.ExportedArgument{{Name: "value", Type: "*math/big.Float"}, {Name: "ratio", Type: "*math/big.Rat"}}, ReturnTypes: []string{"*math/big.Float"}},
+		{Name: "Raise", Arguments: []generator.ExportedArgument{{Name: "level", Type: "example.com/greet.Level"}}},
+	},
+}
+
+func TestGenerateBridgeNumbers(t *testing.T) {
+	bridge, err := generator.GenerateBridge(greeterPackage, numberObjects)
+	if err != nil {
+		t.Fatalf("GenerateBridge should not return error, got %v", err)
+	}
+	source := string(bridge)
+
+	t.Run("should pass complex numbers as text", func(t *testing.T) {
+		for _, expected := range []string{
+			"func melo_mypackage_greet_Rotate(in0 *C.char, out0 **C.char) (failure *C.char) {",
+			"result0 := greet.Rotate(complex64(parseComplex(in0)))",
+			"*out0 = formatComplex(complex128(result0), 128)",
+		} {
+			if !strings.Contains(source, expected) {
+				t.Errorf("GenerateBridge should contain %q, got\n%s", expected, source)
+			}
+		}
+	})
+
+	t.Run("should pass arbitrary precision numbers as text", func(t *testing.T) {
+		for _, expected := range []string{
+			"*out0 = formatBigInt(result0)",
+			"result0 := greet.Scale(parseBigFloat(in0), parseBigRat(in1))",
+			"*out0 = formatBigFloat(result0)",
+		} {
+			if !strings.Contains(source, expected) {
+				t.Errorf("GenerateBridge should contain %q, got\n%s", expected, source)
+			}
+		}
+	})
+}
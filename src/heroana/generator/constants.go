@@ -0,0 +1,140 @@
+package generator
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/EdmilsonRodrigues/melo-project/src/melo/files"
+)
+
+// pythonConstant is a Go constant rendered as a Python literal, along with
+// the Go literal it is checked against. Floats are compared after converting
+// the constant, since untyped constants are more precise than Python floats.
+type pythonConstant struct {
+	Name       string
+	Doc        string
+	PythonType string
+	Python     string
+	Go         string
+	Conversion string
+}
+
+// pythonConstants renders the exported constants as Python literals, so
+// reading them costs no foreign call. Constants without a Python literal,
+// such as floats overflowing float64, are reported and left out.
+func (context *generatorContext) pythonConstants() []pythonConstant {
+	constants := make([]pythonConstant, 0, len(context.objects.ExportedConstants))
+	for _, exportedConstant := range context.objects.ExportedConstants {
+		rendered := pythonConstant{Name: exportedConstant.Name, Doc: exportedConstant.Doc}
+		switch value := exportedConstant.Value.(type) {
+		case string:
+			rendered.PythonType, rendered.Python = "str", strconv.Quote(value)
+			rendered.Go = rendered.Python
+		case bool:
+			rendered.PythonType, rendered.Python = "bool", "False"
+			if value {
+				rendered.Python = "True"
+			}
+			rendered.Go = strconv.FormatBool(value)
+		case rune:
+			rendered.PythonType, rendered.Python = "str", strconv.Quote(string(value))
+			rendered.Go = strconv.QuoteRune(value)
+		case int64:
+			rendered.PythonType, rendered.Python = "int", strconv.FormatInt(value, 10)
+			rendered.Go = rendered.Python
+			if context.resolveType(exportedConstant.Type).kind == durationKind {
+				rendered.PythonType = "datetime.timedelta"
+				rendered.Python = durationLiteral(value)
+			}
+		case *big.Int:
+			rendered.PythonType, rendered.Python = "int", value.String()
+			rendered.Go = rendered.Python
+		case float64:
+			if math.IsInf(value, 0) {
+				log.Printf("Skipping constant %s: %s overflows float64", exportedConstant.Name, exportedConstant.Type)
+				continue
+			}
+			rendered.PythonType, rendered.Python = "float", floatLiteral(value)
+			rendered.Go, rendered.Conversion = rendered.Python, "float64"
+		case complex128:
+			if math.IsInf(real(value), 0) || math.IsInf(imag(value), 0) {
+				log.Printf("Skipping constant %s: %s overflows complex128", exportedConstant.Name, exportedConstant.Type)
+				continue
+			}
+			rendered.PythonType = "complex"
+			rendered.Python = fmt.Sprintf("complex(%s, %s)", floatLiteral(real(value)), floatLiteral(imag(value)))
+			rendered.Go, rendered.Conversion = rendered.Python, "complex128"
+		default:
+			log.Printf("Skipping constant %s: unsupported value %v", exportedConstant.Name, exportedConstant.Value)
+			continue
+		}
+		constants = append(constants, rendered)
+	}
+	return constants
+}
+
+// floatLiteral formats a float with the shortest representation reading back
+// to the same float64, always recognizable as a float by Python.
+func floatLiteral(value float64) string {
+	literal := strconv.FormatFloat(value, 'g', -1, 64)
+	if !strings.ContainsAny(literal, ".e") {
+		literal += ".0"
+	}
+	return literal
+}
+
+// durationLiteral renders nanoseconds as a timedelta, exact up to the
+// microsecond like the durations crossing the bridge.
+func durationLiteral(nanoseconds int64) string {
+	if nanoseconds%1000 == 0 {
+		return fmt.Sprintf("datetime.timedelta(microseconds=%d)", nanoseconds/1000)
+	}
+	return fmt.Sprintf("datetime.timedelta(microseconds=%d / 1000)", nanoseconds)
+}
+
+// writeConstants renders the constants as module level Final literals.
+func (context *generatorContext) writeConstants(definitions *strings.Builder) {
+	constants := context.pythonConstants()
+	for _, rendered := range constants {
+		fmt.Fprintf(definitions, "%s: typing.Final[%s] = %s\n", rendered.Name, rendered.PythonType, rendered.Python)
+		writeDocstring(definitions, "", context.pythonDoc(rendered.Doc))
+	}
+	if len(constants) > 0 {
+		definitions.WriteString("\n\n")
+	}
+}
+
+// GenerateConstantsTest renders a Go test for the bridge package asserting
+// that the literals emitted in the Python module match the Go constants. It
+// is run by melo build, so that building catches values the generator
+// misread.
+func GenerateConstantsTest(exportedPackage files.ExportedPackage, objects ExportedObjects) ([]byte, error) {
+	context := newGeneratorContext(exportedPackage, objects)
+	constants := context.pythonConstants()
+
+	source := &strings.Builder{}
+	source.WriteString(bridgeHeader)
+	source.WriteString("import (\n\t\"testing\"\n")
+	if len(constants) > 0 {
+		fmt.Fprintf(source, "\n\t%s %q\n", context.alias, exportedPackage.GoPath)
+	}
+	source.WriteString(")\n\n")
+
+	fmt.Fprintf(source, "func Test_%s_constants(t *testing.T) {\n", sanitizeIdentifier(exportedPackage.PythonPath))
+	for _, rendered := range constants {
+		goValue := context.alias + "." + rendered.Name
+		if rendered.Conversion != "" {
+			goValue = fmt.Sprintf("%s(%s)", rendered.Conversion, goValue)
+		}
+		fmt.Fprintf(source, "\tif %s != %s {\n", goValue, rendered.Go)
+		fmt.Fprintf(source, "\t\tt.Error(%q)\n", fmt.Sprintf("Python constant %s = %s does not match the Go constant", rendered.Name, rendered.Python))
+		source.WriteString("\t}\n")
+	}
+	source.WriteString("}\n")
+
+	return []byte(source.String()), nil
+}
@@ -0,0 +1,95 @@
+package generator
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/EdmilsonRodrigues/melo-project/src/melo/files"
+)
+
+// Dependency is another exported package of the module, whose types are
+// referenced by the package being generated. Since every bridge is built into
+// the same shared library, values of its types cross as its own handles.
+type Dependency struct {
+	Package files.ExportedPackage
+	Objects ExportedObjects
+}
+
+// addDependencies indexes the dependencies by Go path.
+func (context *generatorContext) addDependencies(dependencies []Dependency) {
+	for _, dependency := range dependencies {
+		if dependency.Package.GoPath == context.exportedPackage.GoPath {
+			continue
+		}
+		context.dependencies[dependency.Package.GoPath] = newGeneratorContext(dependency.Package, dependency.Objects)
+	}
+}
+
+// resolveDependencyType resolves a named type of another package through the
+// dependency exporting it. Types of packages of the module which are not
+// exported are reported once, since the directive is likely missing.
+func (context *generatorContext) resolveDependencyType(typeName string) bridgeType {
+	unsupported := bridgeType{kind: unsupportedKind, goType: typeName}
+	qualifiedName := strings.TrimPrefix(typeName, "*")
+	separator := strings.LastIndex(qualifiedName, ".")
+	if separator < 0 || strings.ContainsAny(qualifiedName, "[]*") {
+		return unsupported
+	}
+	packagePath := qualifiedName[:separator]
+
+	dependency, ok := context.dependencies[packagePath]
+	if !ok {
+		// Standard library paths have no dot in their first element.
+		if firstElement, _, _ := strings.Cut(packagePath, "/"); strings.Contains(firstElement, ".") && !context.reported[packagePath] {
+			context.reported[packagePath] = true
+			log.Printf("Cannot use %s in %s: package %s is not exported, add a \"%s<python path>\" directive to it", typeName, context.exportedPackage.GoPath, packagePath, files.GoExportedDirective)
+		}
+		return unsupported
+	}
+
+	resolved := dependency.resolveType(typeName)
+	switch resolved.kind {
+	case intKind, uintKind, floatKind, complexKind, boolKind, stringKind, structKind, structPointerKind, interfaceKind:
+	case bigIntKind, bigFloatKind, bigRatKind:
+	default:
+		return unsupported
+	}
+
+	alias := context.importDependency(dependency)
+	resolved.goType = strings.Replace(resolved.goType, dependency.alias+".", alias+".", 1)
+	if resolved.name != "" {
+		resolved.name = pythonModuleAlias(dependency.exportedPackage) + "." + resolved.name
+	}
+	return resolved
+}
+
+// importDependency returns the alias under which the bridge imports a
+// dependency, distinct from the other imports.
+func (context *generatorContext) importDependency(dependency *generatorContext) string {
+	goPath := dependency.exportedPackage.GoPath
+	if alias, ok := context.imports[goPath]; ok {
+		return alias
+	}
+
+	taken := map[string]bool{context.alias: true}
+	for _, standardImport := range bridgeStandardImports {
+		taken[standardImport[strings.LastIndex(standardImport, "/")+1:]] = true
+	}
+	for _, alias := range context.imports {
+		taken[alias] = true
+	}
+	alias := dependency.alias
+	for index := 2; taken[alias]; index++ {
+		alias = fmt.Sprintf("%s%d", dependency.alias, index)
+	}
+
+	context.imports[goPath] = alias
+	return alias
+}
+
+// pythonModuleAlias is the name under which a generated module imports the
+// module of a dependency.
+func pythonModuleAlias(exportedPackage files.ExportedPackage) string {
+	return "_" + sanitizeIdentifier(exportedPackage.PythonPath)
+}
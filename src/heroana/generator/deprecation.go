@@ -0,0 +1,39 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// deprecatedPrefix starts the paragraph of a Go doc comment deprecating the
+// declaration, as recognized by go vet and gopls.
+const deprecatedPrefix = "Deprecated:"
+
+// deprecationMessage returns the text of the Deprecated: paragraph of a doc
+// comment, or an empty string.
+func deprecationMessage(doc string) string {
+	for _, paragraph := range strings.Split(doc, "\n\n") {
+		message, ok := strings.CutPrefix(strings.TrimSpace(paragraph), deprecatedPrefix)
+		if ok {
+			return strings.Join(strings.Fields(message), " ")
+		}
+	}
+	return ""
+}
+
+// writeDeprecated decorates a deprecated declaration for type checkers, which
+// report its uses.
+func writeDeprecated(definitions *strings.Builder, indent, message string) {
+	if message == "" {
+		return
+	}
+	fmt.Fprintf(definitions, "%s@_deprecated(%q)\n", indent, message)
+}
+
+// writeDeprecationWarning warns the caller of a deprecated declaration.
+func writeDeprecationWarning(definitions *strings.Builder, indent, name, message string) {
+	if message == "" {
+		return
+	}
+	fmt.Fprintf(definitions, "%swarnings.warn(%q, DeprecationWarning, stacklevel=2)\n", indent, fmt.Sprintf("%s is deprecated: %s", name, message))
+}
@@ -0,0 +1,281 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"log"
+	"slices"
+	"strings"
+
+	"github.com/EdmilsonRodrigues/melo-project/src/melo/files"
+)
+
+// The directives of declarations, written in their doc comment.
+// "// melo:ignore" leaves a declaration out, "// melo:name py_name" renames
+// a function, method or field in Python, "// melo:async" turns a function
+// or method into a coroutine and "// melo:asgi" serves an http.Handler.
+const (
+	ignoreDirective = files.IgnoreDirective
+	nameDirective   = files.NameDirective
+	asyncDirective  = files.AsyncDirective
+	asgiDirective   = files.ASGIDirective
+)
+
+// The declarations directives apply to.
+const (
+	functionTarget        = "function"
+	genericFunctionTarget = "generic function"
+	methodTarget          = "method"
+	typeTarget            = "type"
+	genericTypeTarget     = "generic type"
+	constantTarget        = "constant"
+	variableTarget        = "variable"
+	fieldTarget           = "field"
+	interfaceMethodTarget = "interface method"
+)
+
+// directiveRule is the number of arguments a directive takes, unbounded
+// when MaxArguments is negative, and the declarations it applies to.
+type directiveRule struct {
+	MinArguments int
+	MaxArguments int
+	Targets      []string
+}
+
+var directiveRules = map[string]directiveRule{
+	ignoreDirective:      {0, 0, []string{functionTarget, genericFunctionTarget, methodTarget, typeTarget, genericTypeTarget, constantTarget, variableTarget, fieldTarget}},
+	nameDirective:        {1, 1, []string{functionTarget, methodTarget, fieldTarget}},
+	readonlyDirective:    {0, 0, []string{variableTarget, fieldTarget}},
+	asyncDirective:       {0, 0, []string{functionTarget, methodTarget}},
+	asgiDirective:        {0, 0, []string{functionTarget, methodTarget}},
+	instantiateDirective: {1, -1, []string{genericFunctionTarget, genericTypeTarget}},
+}
+
+// declarationDirectives are the directives of a declaration. Instantiations
+// are read by instantiations, since they depend on the type parameters.
+type declarationDirectives struct {
+	Ignore   bool
+	Name     string
+	ReadOnly bool
+	Async    bool
+	ASGI     bool
+}
+
+// parseDeclarationDirectives reads the directives split out of a doc comment
+// by parseDirectives, checked beforehand by checkDirectives.
+func parseDeclarationDirectives(directives []string) declarationDirectives {
+	parsed := declarationDirectives{}
+	for _, directive := range directives {
+		key, argument, _ := strings.Cut(directive, " ")
+		switch key {
+		case ignoreDirective:
+			parsed.Ignore = true
+		case nameDirective:
+			parsed.Name = strings.TrimSpace(argument)
+		case readonlyDirective:
+			parsed.ReadOnly = true
+		case asyncDirective:
+			parsed.Async = true
+		case asgiDirective:
+			parsed.ASGI = true
+		}
+	}
+	return parsed
+}
+
+// checkDirectives reports the "// melo:" directives of the files that are
+// unknown, malformed or misplaced, along with their position. The directive
+// leading the package clause is read by files.ScanModule.
+func checkDirectives(fileSet *token.FileSet, syntax []*ast.File) error {
+	errs := []error{}
+	for _, file := range syntax {
+		targets := directiveTargets(file)
+		for _, group := range file.Comments {
+			if group.End() < file.Package {
+				continue
+			}
+			for _, line := range group.List {
+				text, ok := strings.CutPrefix(line.Text, files.GoExportedDirective)
+				if !ok {
+					continue
+				}
+				if err := checkDirective(text, targets[group]); err != nil {
+					errs = append(errs, fmt.Errorf("%s: %w", fileSet.Position(line.Slash), err))
+				}
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func checkDirective(text, target string) error {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return fmt.Errorf("empty %q directive", files.GoExportedDirective)
+	}
+	key, arguments := fields[0], fields[1:]
+	rule, ok := directiveRules[key]
+	switch {
+	case !ok:
+		return fmt.Errorf("unknown directive %q", key)
+	case target == "":
+		return fmt.Errorf("the %q directive is not in the doc comment of a declaration", key)
+	case !slices.Contains(rule.Targets, target):
+		return fmt.Errorf("the %q directive does not apply to %ss", key, target)
+	case rule.MaxArguments == 0 && len(arguments) > 0:
+		return fmt.Errorf("the %q directive takes no argument, got %q", key, strings.Join(arguments, " "))
+	case len(arguments) < rule.MinArguments:
+		return fmt.Errorf("the %q directive needs an argument", key)
+	case rule.MaxArguments > 0 && len(arguments) > rule.MaxArguments:
+		return fmt.Errorf("the %q directive takes a single argument, got %q", key, strings.Join(arguments, " "))
+	}
+	if key == nameDirective && (!pythonIdentifierPattern.MatchString(arguments[0]) || pythonKeywords[arguments[0]]) {
+		return fmt.Errorf("%q is not a Python identifier", arguments[0])
+	}
+	return nil
+}
+
+// directiveTargets maps the doc and trailing comments of the declarations of
+// a file to the kind of declaration they document.
+func directiveTargets(file *ast.File) map[*ast.CommentGroup]string {
+	targets := map[*ast.CommentGroup]string{}
+	add := func(group *ast.CommentGroup, target string) {
+		if group != nil && targets[group] == "" {
+			targets[group] = target
+		}
+	}
+	addFields := func(fields *ast.FieldList, target string) {
+		for _, field := range fields.List {
+			add(field.Doc, target)
+			add(field.Comment, target)
+		}
+	}
+
+	ast.Inspect(file, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.FuncDecl:
+			switch {
+			case node.Recv != nil:
+				add(node.Doc, methodTarget)
+			case node.Type.TypeParams != nil:
+				add(node.Doc, genericFunctionTarget)
+			default:
+				add(node.Doc, functionTarget)
+			}
+		case *ast.GenDecl:
+			for _, spec := range node.Specs {
+				target := ""
+				switch spec := spec.(type) {
+				case *ast.ValueSpec:
+					target = variableTarget
+					if node.Tok == token.CONST {
+						target = constantTarget
+					}
+					add(spec.Doc, target)
+					add(spec.Comment, target)
+				case *ast.TypeSpec:
+					target = typeTarget
+					if spec.TypeParams != nil {
+						target = genericTypeTarget
+					}
+					add(spec.Doc, target)
+					add(spec.Comment, target)
+				}
+				add(node.Doc, target)
+			}
+		case *ast.StructType:
+			addFields(node.Fields, fieldTarget)
+		case *ast.InterfaceType:
+			addFields(node.Methods, interfaceMethodTarget)
+		}
+		return true
+	})
+	return targets
+}
+
+// pythonRoutineName is the Python name of a function or method: the name
+// given by its directive, or else its Go name in the style of the package.
+func (context *generatorContext) pythonRoutineName(routine ExportedRoutine) string {
+	if name := parseDeclarationDirectives(routine.Directives).Name; name != "" {
+		return name
+	}
+	name := context.styledName(routine.Name)
+	// Functions shadowing the builtins the module relies on, such as len or
+	// int, get a trailing underscore.
+	if pythonBuiltins[name] && context.moduleFunction(routine.Name) {
+		name += "_"
+	}
+	return name
+}
+
+// moduleFunction reports whether a name is a function of the module rather
+// than a method.
+func (context *generatorContext) moduleFunction(name string) bool {
+	if findFunctionByName(context.objects.ExportedFunctions, name) != nil {
+		return true
+	}
+	return slices.ContainsFunc(context.generics, func(generic genericFunction) bool { return generic.Generic.Name == name })
+}
+
+// styledName spells a Go name in the name style of the package, snake_case
+// with the snake style.
+func (context *generatorContext) styledName(name string) string {
+	if context.exportedPackage.NameStyle != files.SnakeNameStyle {
+		return name
+	}
+	return argumentName(snakeCase(name), 0)
+}
+
+// excludeObjects leaves out the declarations listed by the exclude option of
+// the package directive, either top-level names or the fields and methods of
+// structs as Type.Member. Names matching no declaration are kept for
+// reportExclusions.
+func (context *generatorContext) excludeObjects(objects ExportedObjects) ExportedObjects {
+	if len(context.exportedPackage.Exclude) == 0 {
+		return objects
+	}
+	matched := map[string]bool{}
+	excluded := func(name string) bool {
+		if slices.Contains(context.exportedPackage.Exclude, name) {
+			matched[name] = true
+			return true
+		}
+		return false
+	}
+
+	objects.ExportedConstants = slices.DeleteFunc(slices.Clone(objects.ExportedConstants), func(constant ExportedConstant) bool { return excluded(constant.Name) })
+	objects.ExportedVariables = slices.DeleteFunc(slices.Clone(objects.ExportedVariables), func(variable ExportedVariable) bool { return excluded(variable.Name) })
+	objects.ExportedTypes = slices.DeleteFunc(slices.Clone(objects.ExportedTypes), func(exportedType ExportedType) bool { return excluded(exportedType.Name) })
+	objects.ExportedAliases = slices.DeleteFunc(slices.Clone(objects.ExportedAliases), func(alias ExportedAlias) bool { return excluded(alias.Name) })
+	objects.ExportedInterfaces = slices.DeleteFunc(slices.Clone(objects.ExportedInterfaces), func(exportedInterface ExportedInterface) bool { return excluded(exportedInterface.Name) })
+	objects.ExportedFunctions = slices.DeleteFunc(slices.Clone(objects.ExportedFunctions), func(function ExportedRoutine) bool { return excluded(function.Name) })
+	objects.ExportedStructs = slices.DeleteFunc(slices.Clone(objects.ExportedStructs), func(exportedStruct ExportedStruct) bool { return excluded(exportedStruct.Name) })
+	for index := range objects.ExportedStructs {
+		exportedStruct := &objects.ExportedStructs[index]
+		exportedStruct.Fields = slices.DeleteFunc(slices.Clone(exportedStruct.Fields), func(field ExportedField) bool {
+			return excluded(exportedStruct.Name + "." + field.Name)
+		})
+		exportedStruct.Methods = slices.DeleteFunc(slices.Clone(exportedStruct.Methods), func(method ExportedRoutine) bool {
+			return excluded(exportedStruct.Name + "." + method.Name)
+		})
+	}
+
+	for _, name := range context.exportedPackage.Exclude {
+		if !matched[name] {
+			context.unmatchedExclusions = append(context.unmatchedExclusions, name)
+		}
+	}
+	return objects
+}
+
+// reportExclusions logs the names of the exclude option matching no
+// declaration. A context is built for the bridge and for every package
+// depending on this one as well, so only the Python module and the docs,
+// each rendered once per package, report them.
+func (context *generatorContext) reportExclusions() {
+	for _, name := range context.unmatchedExclusions {
+		log.Printf("Cannot exclude %s from %s: no such declaration, or a member of an interface", name, context.exportedPackage.GoPath)
+	}
+}
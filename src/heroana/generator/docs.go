@@ -0,0 +1,591 @@
+package generator
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/doc/comment"
+	"go/format"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"strings"
+
+	"github.com/EdmilsonRodrigues/melo-project/src/melo/files"
+)
+
+// Formats of the API reference rendered by GenerateDocs.
+const (
+	MarkdownFormat = "markdown"
+	RestFormat     = "rst"
+)
+
+// restIndent indents the content of reStructuredText directives.
+const restIndent = "   "
+
+// InspectDeclarations reads the Go source of the exported declarations of a
+// package, keyed by name. Methods, struct fields and interface methods are
+// keyed by the name of their type and their own, such as "Canvas.Draw". The
+// package is loaded like InspectPackage does.
+func InspectDeclarations(directory, packagePath string) (map[string]GoDeclaration, error) {
+	pkg, err := getPackage(directory, packagePath)
+	if err != nil {
+		return nil, fmt.Errorf("error inspecting declarations: %w", err)
+	}
+
+	declarations := map[string]GoDeclaration{}
+	for _, file := range pkg.Syntax {
+		for _, declaration := range file.Decls {
+			switch declaration := declaration.(type) {
+			case *ast.FuncDecl:
+				if !declaration.Name.IsExported() {
+					continue
+				}
+				name := declaration.Name.Name
+				if declaration.Recv != nil && len(declaration.Recv.List) > 0 {
+					name = receiverName(declaration.Recv.List[0].Type) + "." + name
+				}
+				signature := *declaration
+				signature.Doc, signature.Body = nil, nil
+				declarations[name] = GoDeclaration{Source: printNode(pkg.Fset, &signature), Position: position(pkg.Fset, declaration.Pos())}
+
+			case *ast.GenDecl:
+				for _, spec := range declaration.Specs {
+					single := &ast.GenDecl{Tok: declaration.Tok, Specs: []ast.Spec{spec}}
+					switch spec := spec.(type) {
+					case *ast.ValueSpec:
+						for _, name := range spec.Names {
+							if name.IsExported() {
+								declarations[name.Name] = GoDeclaration{Source: printNode(pkg.Fset, single), Position: position(pkg.Fset, name.Pos())}
+							}
+						}
+					case *ast.TypeSpec:
+						if !spec.Name.IsExported() {
+							continue
+						}
+						declarations[spec.Name.Name] = GoDeclaration{Source: printNode(pkg.Fset, single), Position: position(pkg.Fset, spec.Pos())}
+						for name, member := range memberDeclarations(pkg.Fset, spec) {
+							declarations[spec.Name.Name+"."+name] = member
+						}
+					}
+				}
+			}
+		}
+	}
+	return declarations, nil
+}
+
+// memberDeclarations reads the exported fields of a struct type or the
+// methods of an interface type.
+func memberDeclarations(fileSet *token.FileSet, spec *ast.TypeSpec) map[string]GoDeclaration {
+	members := map[string]GoDeclaration{}
+	switch typeExpression := spec.Type.(type) {
+	case *ast.StructType:
+		for _, field := range typeExpression.Fields.List {
+			source := types.ExprString(field.Type)
+			if field.Tag != nil {
+				source += " " + field.Tag.Value
+			}
+			if len(field.Names) == 0 {
+				name := receiverName(field.Type)
+				members[name] = GoDeclaration{Source: source, Position: position(fileSet, field.Pos())}
+			}
+			for _, name := range field.Names {
+				members[name.Name] = GoDeclaration{Source: name.Name + " " + source, Position: position(fileSet, name.Pos())}
+			}
+		}
+	case *ast.InterfaceType:
+		for _, method := range typeExpression.Methods.List {
+			for _, name := range method.Names {
+				source := name.Name + strings.TrimPrefix(types.ExprString(method.Type), "func")
+				members[name.Name] = GoDeclaration{Source: source, Position: position(fileSet, name.Pos())}
+			}
+		}
+	}
+	return members
+}
+
+// receiverName is the name of the type of a receiver or an embedded field.
+func receiverName(expression ast.Expr) string {
+	for {
+		switch typed := expression.(type) {
+		case *ast.StarExpr:
+			expression = typed.X
+		case *ast.IndexExpr:
+			expression = typed.X
+		case *ast.IndexListExpr:
+			expression = typed.X
+		case *ast.SelectorExpr:
+			return typed.Sel.Name
+		case *ast.Ident:
+			return typed.Name
+		default:
+			return types.ExprString(expression)
+		}
+	}
+}
+
+func printNode(fileSet *token.FileSet, node any) string {
+	buffer := &bytes.Buffer{}
+	if err := format.Node(buffer, fileSet, node); err != nil {
+		return ""
+	}
+	return buffer.String()
+}
+
+func position(fileSet *token.FileSet, pos token.Pos) string {
+	position := fileSet.Position(pos)
+	return fmt.Sprintf("%s:%d", filepath.Base(position.Filename), position.Line)
+}
+
+// docEntry is a Python declaration of the generated module, along with the
+// Go declarations it comes from.
+type docEntry struct {
+	Role       string // Sphinx directive: data, class, property, method, classmethod or function
+	Name       string
+	Parameters []string
+	Returns    string
+	Bases      string
+	Type       string
+	Value      string
+	ReadOnly   bool
+	Async      bool
+	GoNames    []string
+	Doc        string // Go doc comment
+	Docstring  string // Docstring of the generated Python declaration
+	Examples   string // Doctests translated from the Go Example functions
+	Members    []docEntry
+}
+
+// docSection is a titled group of declarations of the API reference.
+type docSection struct {
+	Title   string
+	Entries []docEntry
+}
+
+// GenerateDocs renders the API reference of the Python module generated for
+// a package, in Markdown or in reStructuredText for Sphinx. The Go
+// declarations are read from the GoDeclarations of the objects.
+func GenerateDocs(exportedPackage files.ExportedPackage, objects ExportedObjects, docsFormat string, dependencies ...Dependency) (string, error) {
+	context := newGeneratorContext(exportedPackage, objects)
+	context.addDependencies(dependencies)
+	context.reportExclusions()
+
+	sections := context.docSections()
+	switch docsFormat {
+	case MarkdownFormat:
+		return context.markdownDocs(sections), nil
+	case RestFormat:
+		return context.restDocs(sections), nil
+	}
+	return "", fmt.Errorf("unknown documentation format %q", docsFormat)
+}
+
+func (context *generatorContext) docSections() []docSection {
+	constants := []docEntry{}
+	for _, rendered := range context.pythonConstants() {
+		constants = append(constants, docEntry{
+			Role:      "data",
+			Name:      rendered.Name,
+			Type:      fmt.Sprintf("typing.Final[%s]", rendered.PythonType),
+			Value:     rendered.Python,
+			GoNames:   []string{rendered.Name},
+			Doc:       rendered.Doc,
+			Docstring: context.pythonDoc(rendered.Doc),
+		})
+	}
+
+	variables := []docEntry{}
+	for _, variable := range context.exportedVariables() {
+		variables = append(variables, docEntry{
+			Role:      "data",
+			Name:      variable.Name,
+			Type:      variable.Bridge.pythonType(),
+			ReadOnly:  variable.ReadOnly,
+			GoNames:   []string{variable.Name},
+			Doc:       variable.Doc,
+			Docstring: context.pythonDoc(variable.Doc),
+		})
+	}
+
+	classes := []docEntry{}
+	for _, exportedStruct := range context.objects.ExportedStructs {
+		classes = append(classes, context.classEntry(exportedStruct))
+	}
+
+	protocols := []docEntry{}
+	for _, exportedInterface := range context.objects.ExportedInterfaces {
+		if !context.proxyable(exportedInterface) {
+			continue
+		}
+		protocol := docEntry{
+			Role:      "class",
+			Name:      exportedInterface.Name,
+			Bases:     "typing.Protocol",
+			GoNames:   []string{exportedInterface.Name},
+			Doc:       exportedInterface.Doc,
+			Docstring: context.pythonDoc(exportedInterface.Doc),
+		}
+		for _, method := range exportedInterface.Methods {
+			call, _ := context.resolveRoutine(method, context.symbol(exportedInterface.Name, method.Name))
+			protocol.Members = append(protocol.Members, callEntry("method", call, exportedInterface.Name+"."+method.Name, method.Doc))
+		}
+		protocols = append(protocols, protocol)
+	}
+
+	doctests := map[string]string{}
+	if context.exportedPackage.Doctests {
+		doctests = context.doctests()
+	}
+	functions := []docEntry{}
+	for _, function := range context.objects.ExportedFunctions {
+		if _, _, constructor := context.constructorOf(function); constructor {
+			continue
+		}
+		call, ok := context.resolveRoutine(function, context.symbol(function.Name))
+		if !ok {
+			continue
+		}
+		call.Examples = doctests[function.Name]
+		functions = append(functions, callEntry("function", call, context.declarationName(function.Name), function.Doc))
+	}
+
+	aliases := []docEntry{}
+	for _, alias := range context.objects.ExportedAliases {
+		aliasType := context.resolveType(alias.Type)
+		if !aliasType.aliasable() {
+			continue
+		}
+		aliases = append(aliases, docEntry{
+			Role:      "data",
+			Name:      alias.Name,
+			Value:     aliasType.pythonType(),
+			GoNames:   []string{alias.Name},
+			Doc:       alias.Doc,
+			Docstring: context.pythonDoc(alias.Doc),
+		})
+	}
+
+	return []docSection{
+		{Title: "Constants", Entries: constants},
+		{Title: "Variables", Entries: variables},
+		{Title: "Classes", Entries: classes},
+		{Title: "Protocols", Entries: protocols},
+		{Title: "Functions", Entries: functions},
+		{Title: "Aliases", Entries: aliases},
+	}
+}
+
+// classEntry documents the class of a struct, built by its primary
+// constructor or by keyword arguments naming its fields.
+func (context *generatorContext) classEntry(exportedStruct ExportedStruct) docEntry {
+	goName := context.declarationName(exportedStruct.Name)
+	class := docEntry{
+		Role:      "class",
+		Name:      exportedStruct.Name,
+		GoNames:   []string{goName},
+		Doc:       exportedStruct.Doc,
+		Docstring: context.pythonDoc(exportedStruct.Doc),
+	}
+
+	properties := context.exportedFields(exportedStruct)
+	constructed := false
+	for _, function := range context.objects.ExportedFunctions {
+		structName, variant, ok := context.constructorOf(function)
+		if !ok || structName != exportedStruct.Name {
+			continue
+		}
+		call, ok := context.resolveRoutine(function, context.symbol(function.Name))
+		if !ok {
+			continue
+		}
+		if variant == "" {
+			class.Parameters, _ = pythonArguments(call)
+			class.GoNames = append(class.GoNames, function.Name)
+			constructed = true
+			continue
+		}
+		call.Name = argumentName(snakeCase(variant), 0)
+		variantEntry := callEntry("classmethod", call, function.Name, function.Doc)
+		variantEntry.Returns = "typing.Self"
+		variantEntry.Docstring = callDocstring(call, false)
+		class.Members = append(class.Members, variantEntry)
+	}
+	if !constructed {
+		for _, field := range properties {
+			if field.ReadOnly {
+				continue
+			}
+			if len(class.Parameters) == 0 {
+				class.Parameters = []string{"*"}
+			}
+			class.Parameters = append(class.Parameters, fmt.Sprintf("%s: %s | None = None", field.PythonName, field.Bridge.pythonType()))
+		}
+	}
+
+	for _, field := range properties {
+		class.Members = append(class.Members, docEntry{
+			Role:      "property",
+			Name:      field.PythonName,
+			Type:      field.Bridge.pythonType(),
+			ReadOnly:  field.ReadOnly,
+			GoNames:   []string{goName + "." + field.Name},
+			Doc:       field.Doc,
+			Docstring: context.pythonDoc(field.Doc),
+		})
+	}
+	for _, method := range exportedStruct.Methods {
+		call, ok := context.resolveRoutine(method, context.symbol(exportedStruct.Name, method.Name))
+		if !ok {
+			continue
+		}
+		// Close is wrapped by the close method of Closable.
+		if call.GoName == "Close" && !call.Async && len(call.Arguments) == 0 && len(call.Results) == 0 {
+			call.Name = context.styledName("Close")
+		}
+		class.Members = append(class.Members, callEntry("method", call, goName+"."+method.Name, method.Doc))
+	}
+	return class
+}
+
+func callEntry(role string, call bridgeCall, goName, doc string) docEntry {
+	parameters, _ := pythonArguments(call)
+	return docEntry{
+		Role:       role,
+		Name:       call.Name,
+		Parameters: parameters,
+		Returns:    pythonReturnType(call.Results),
+		Async:      call.Async,
+		GoNames:    []string{goName},
+		Doc:        doc,
+		Docstring:  callDocstring(call, true),
+		Examples:   call.Examples,
+	}
+}
+
+// declarationName is the name a Go declaration is inspected under, the
+// generic one for instantiations of generics.
+func (context *generatorContext) declarationName(name string) string {
+	name, _, _ = strings.Cut(context.goName(name), "[")
+	return name
+}
+
+// goSource is the Go source of the declarations of an entry, each preceded
+// by a comment with its position.
+func (context *generatorContext) goSource(entry docEntry) string {
+	sources := []string{}
+	for _, goName := range entry.GoNames {
+		declaration, ok := context.objects.GoDeclarations[goName]
+		if !ok {
+			continue
+		}
+		sources = append(sources, fmt.Sprintf("// %s\n%s", declaration.Position, strings.TrimRight(declaration.Source, "\n")))
+	}
+	return strings.Join(sources, "\n\n")
+}
+
+// pythonSignature is the declaration of an entry as written in Python.
+func (entry docEntry) pythonSignature() string {
+	parameters := strings.Join(entry.Parameters, ", ")
+	def := "def"
+	if entry.Async {
+		def = "async def"
+	}
+	switch entry.Role {
+	case "function":
+		return fmt.Sprintf("%s %s(%s) -> %s", def, entry.Name, parameters, entry.Returns)
+	case "method":
+		return fmt.Sprintf("%s %s(%s) -> %s", def, entry.Name, strings.Join(append([]string{"self"}, entry.Parameters...), ", "), entry.Returns)
+	case "classmethod":
+		return fmt.Sprintf("@classmethod\ndef %s(%s) -> %s", entry.Name, strings.Join(append([]string{"cls"}, entry.Parameters...), ", "), entry.Returns)
+	case "class":
+		if entry.Bases != "" {
+			return fmt.Sprintf("class %s(%s)", entry.Name, entry.Bases)
+		}
+		return fmt.Sprintf("class %s(%s)", entry.Name, parameters)
+	}
+
+	signature := entry.Name
+	if entry.Type != "" {
+		signature += ": " + entry.Type
+	}
+	if entry.Value != "" {
+		signature += " = " + entry.Value
+	}
+	return signature
+}
+
+func (context *generatorContext) markdownDocs(sections []docSection) string {
+	builder := &strings.Builder{}
+	fmt.Fprintf(builder, "# `%s`\n\n", context.exportedPackage.PythonPath)
+	fmt.Fprintf(builder, "Python module generated from the Go package `%s`.\n", context.exportedPackage.GoPath)
+	for _, section := range sections {
+		if len(section.Entries) == 0 {
+			continue
+		}
+		fmt.Fprintf(builder, "\n## %s\n", section.Title)
+		for _, entry := range section.Entries {
+			context.writeMarkdownEntry(builder, entry, "", 3)
+		}
+	}
+	return builder.String()
+}
+
+func (context *generatorContext) writeMarkdownEntry(builder *strings.Builder, entry docEntry, parent string, level int) {
+	name := entry.Name
+	if parent != "" {
+		name = parent + "." + entry.Name
+	}
+	fmt.Fprintf(builder, "\n<a id=\"%s\"></a>\n\n%s `%s`\n\n", name, strings.Repeat("#", level), name)
+	fmt.Fprintf(builder, "```python\n%s\n```\n", entry.pythonSignature())
+	if entry.ReadOnly {
+		builder.WriteString("\nRead-only.\n")
+	}
+	if source := context.goSource(entry); source != "" {
+		fmt.Fprintf(builder, "\n```go\n%s\n```\n", source)
+	}
+	if doc := context.markdownDoc(entry.Doc, level+1); doc != "" {
+		fmt.Fprintf(builder, "\n%s", doc)
+	}
+	if entry.Examples != "" {
+		fmt.Fprintf(builder, "\nExamples:\n\n```pycon\n%s\n```\n", entry.Examples)
+	}
+	for _, member := range entry.Members {
+		context.writeMarkdownEntry(builder, member, name, level+1)
+	}
+}
+
+// markdownDoc renders a Go doc comment as Markdown, with the doc links to
+// declarations exported to Python pointing at their entries.
+func (context *generatorContext) markdownDoc(doc string, headingLevel int) string {
+	if strings.TrimSpace(doc) == "" {
+		return ""
+	}
+	parser := comment.Parser{
+		LookupPackage: context.lookupPackage,
+		LookupSym: func(recv, name string) bool {
+			return context.pythonName(recv, name) != ""
+		},
+	}
+	parsed := parser.Parse(doc)
+	for _, block := range parsed.Content {
+		context.renameDocLinks(block)
+	}
+
+	printer := comment.Printer{
+		HeadingLevel: min(headingLevel, 6),
+		HeadingID:    func(*comment.Heading) string { return "" },
+		DocLinkURL: func(link *comment.DocLink) string {
+			target, role, name, ok := context.docLinkTarget(link)
+			if !ok {
+				return link.DefaultURL("https://pkg.go.dev")
+			}
+			page := ""
+			if target != context {
+				page = target.exportedPackage.PythonPath + ".md"
+			}
+			if role == "mod" {
+				return page
+			}
+			return page + "#" + name
+		},
+	}
+	return string(printer.Markdown(parsed))
+}
+
+// renameDocLinks replaces the text of the doc links to declarations exported
+// to Python by their Python names.
+func (context *generatorContext) renameDocLinks(block comment.Block) {
+	rename := func(texts []comment.Text) {
+		for _, text := range texts {
+			link, ok := text.(*comment.DocLink)
+			if !ok {
+				continue
+			}
+			if target, role, name, ok := context.docLinkTarget(link); ok {
+				if role != "mod" && target != context {
+					name = target.exportedPackage.PythonPath + "." + name
+				}
+				link.Text = []comment.Text{comment.Plain(name)}
+			}
+		}
+	}
+	switch block := block.(type) {
+	case *comment.Heading:
+		rename(block.Text)
+	case *comment.Paragraph:
+		rename(block.Text)
+	case *comment.List:
+		for _, item := range block.Items {
+			for _, content := range item.Content {
+				context.renameDocLinks(content)
+			}
+		}
+	}
+}
+
+func (context *generatorContext) restDocs(sections []docSection) string {
+	builder := &strings.Builder{}
+	title := context.exportedPackage.PythonPath
+	fmt.Fprintf(builder, "%s\n%s\n\n", title, strings.Repeat("=", len(title)))
+	fmt.Fprintf(builder, ".. py:module:: %s\n\n", title)
+	fmt.Fprintf(builder, "Python module generated from the Go package ``%s``.\n", context.exportedPackage.GoPath)
+	for _, section := range sections {
+		if len(section.Entries) == 0 {
+			continue
+		}
+		fmt.Fprintf(builder, "\n%s\n%s\n", section.Title, strings.Repeat("-", len(section.Title)))
+		for _, entry := range section.Entries {
+			context.writeRestEntry(builder, entry, "")
+		}
+	}
+	return builder.String()
+}
+
+func (context *generatorContext) writeRestEntry(builder *strings.Builder, entry docEntry, indent string) {
+	contentIndent := indent + restIndent
+	switch entry.Role {
+	case "data", "property":
+		fmt.Fprintf(builder, "\n%s.. py:%s:: %s\n", indent, entry.Role, entry.Name)
+		if entry.Type != "" {
+			fmt.Fprintf(builder, "%s:type: %s\n", contentIndent, entry.Type)
+		}
+		if entry.Value != "" {
+			fmt.Fprintf(builder, "%s:value: %s\n", contentIndent, entry.Value)
+		}
+	case "class":
+		fmt.Fprintf(builder, "\n%s.. py:class:: %s(%s)\n", indent, entry.Name, strings.Join(entry.Parameters, ", "))
+		if entry.Bases != "" {
+			fmt.Fprintf(builder, "\n%sBases: :class:`%s`\n", contentIndent, entry.Bases)
+		}
+	default:
+		fmt.Fprintf(builder, "\n%s.. py:%s:: %s(%s) -> %s\n", indent, entry.Role, entry.Name, strings.Join(entry.Parameters, ", "), entry.Returns)
+		if entry.Async {
+			fmt.Fprintf(builder, "%s:async:\n", contentIndent)
+		}
+	}
+
+	paragraphs := []string{}
+	if entry.ReadOnly {
+		paragraphs = append(paragraphs, "Read-only.")
+	}
+	if entry.Docstring != "" {
+		paragraphs = append(paragraphs, entry.Docstring)
+	}
+	if source := context.goSource(entry); source != "" {
+		paragraphs = append(paragraphs, ".. code-block:: go\n\n"+pythonIndent+strings.ReplaceAll(source, "\n", "\n"+pythonIndent))
+	}
+	for _, paragraph := range paragraphs {
+		builder.WriteString("\n")
+		for _, line := range strings.Split(paragraph, "\n") {
+			if strings.TrimSpace(line) == "" {
+				builder.WriteString("\n")
+				continue
+			}
+			fmt.Fprintf(builder, "%s%s\n", contentIndent, line)
+		}
+	}
+	for _, member := range entry.Members {
+		context.writeRestEntry(builder, member, contentIndent)
+	}
+}
@@ -0,0 +1,252 @@
+package generator
+
+import (
+	"fmt"
+	"go/doc/comment"
+	"strings"
+)
+
+// docstringWidth is the width docstring paragraphs are wrapped at.
+const docstringWidth = 72
+
+// pythonDoc renders a Go doc comment as a reStructuredText docstring, with
+// the doc links to exported declarations rewritten to their Python names.
+func (context *generatorContext) pythonDoc(doc string) string {
+	if strings.TrimSpace(doc) == "" {
+		return ""
+	}
+	parser := comment.Parser{
+		LookupPackage: context.lookupPackage,
+		LookupSym: func(recv, name string) bool {
+			return context.pythonName(recv, name) != ""
+		},
+	}
+	parsed := parser.Parse(doc)
+
+	blocks := make([]string, 0, len(parsed.Content))
+	for _, block := range parsed.Content {
+		blocks = append(blocks, context.renderBlock(block, ""))
+	}
+	return strings.Join(blocks, "\n\n")
+}
+
+// lookupPackage resolves the package names of doc links, exported
+// dependencies first.
+func (context *generatorContext) lookupPackage(name string) (string, bool) {
+	for goPath, dependency := range context.dependencies {
+		if dependency.alias == name {
+			return goPath, true
+		}
+	}
+	return comment.DefaultLookupPackage(name)
+}
+
+func (context *generatorContext) renderBlock(block comment.Block, indent string) string {
+	switch block := block.(type) {
+	case *comment.Heading:
+		heading := context.renderText(block.Text)
+		return heading + "\n" + strings.Repeat("-", len(heading))
+	case *comment.Paragraph:
+		return wrapText(context.renderText(block.Text), docstringWidth-len(indent), indent)
+	case *comment.Code:
+		lines := []string{".. code-block:: go", ""}
+		for _, line := range strings.Split(strings.TrimRight(block.Text, "\n"), "\n") {
+			lines = append(lines, strings.TrimRight(pythonIndent+line, " "))
+		}
+		return strings.Join(lines, "\n")
+	case *comment.List:
+		items := make([]string, 0, len(block.Items))
+		for _, item := range block.Items {
+			marker := "- "
+			if item.Number != "" {
+				marker = item.Number + ". "
+			}
+			continuation := strings.Repeat(" ", len(marker))
+			parts := make([]string, 0, len(item.Content))
+			for _, content := range item.Content {
+				parts = append(parts, context.renderBlock(content, continuation))
+			}
+			items = append(items, marker+strings.TrimPrefix(strings.Join(parts, "\n\n"+continuation), continuation))
+		}
+		separator := "\n"
+		if block.BlankBetween() {
+			separator = "\n\n"
+		}
+		return strings.Join(items, separator)
+	}
+	return ""
+}
+
+func (context *generatorContext) renderText(texts []comment.Text) string {
+	builder := &strings.Builder{}
+	for _, text := range texts {
+		switch text := text.(type) {
+		case comment.Plain:
+			builder.WriteString(string(text))
+		case comment.Italic:
+			fmt.Fprintf(builder, "*%s*", text)
+		case *comment.Link:
+			if text.Auto {
+				builder.WriteString(text.URL)
+				continue
+			}
+			fmt.Fprintf(builder, "`%s <%s>`_", context.renderText(text.Text), text.URL)
+		case *comment.DocLink:
+			builder.WriteString(context.renderDocLink(text))
+		}
+	}
+	return builder.String()
+}
+
+// renderDocLink renders a doc link as a Python cross-reference when it names
+// a declaration exported to Python, and as a literal otherwise.
+func (context *generatorContext) renderDocLink(link *comment.DocLink) string {
+	target, role, name, ok := context.docLinkTarget(link)
+	if !ok {
+		return fmt.Sprintf("``%s``", context.renderText(link.Text))
+	}
+	if role != "mod" && target != context {
+		name = target.exportedPackage.PythonPath + "." + name
+	}
+	return fmt.Sprintf(":%s:`%s`", role, name)
+}
+
+// docLinkTarget resolves a doc link to the context of the exported package it
+// points into, along with the Sphinx role and the Python name of the linked
+// declaration, or of the module for links to a package.
+func (context *generatorContext) docLinkTarget(link *comment.DocLink) (target *generatorContext, role, name string, ok bool) {
+	target = context
+	if link.ImportPath != "" && link.ImportPath != context.exportedPackage.GoPath {
+		if target, ok = context.dependencies[link.ImportPath]; !ok {
+			return nil, "", "", false
+		}
+	}
+	if link.Name == "" {
+		return target, "mod", target.exportedPackage.PythonPath, true
+	}
+
+	role, name, ok = strings.Cut(target.pythonName(link.Recv, link.Name), " ")
+	return target, role, name, ok
+}
+
+// pythonName returns the Sphinx role and the Python name of an exported
+// declaration, separated by a space, or an empty string when the declaration
+// is not exported to Python. Constructors are folded into their class.
+func (context *generatorContext) pythonName(recv, name string) string {
+	objects := context.objects
+	if recv != "" {
+		if exportedStruct := findStructByName(objects.ExportedStructs, recv); exportedStruct != nil {
+			if method := findFunctionByName(exportedStruct.Methods, name); method != nil {
+				return "meth " + recv + "." + context.pythonRoutineName(*method)
+			}
+		}
+		if exportedInterface := findInterfaceByName(objects.ExportedInterfaces, recv); exportedInterface != nil {
+			if method := findFunctionByName(exportedInterface.Methods, name); method != nil {
+				return "meth " + recv + "." + context.pythonRoutineName(*method)
+			}
+		}
+		return ""
+	}
+
+	if function := findFunctionByName(objects.ExportedFunctions, name); function != nil {
+		structName, variant, ok := context.constructorOf(*function)
+		switch {
+		case !ok:
+			return "func " + context.pythonRoutineName(*function)
+		case variant == "":
+			return "class " + structName
+		default:
+			return "meth " + structName + "." + argumentName(snakeCase(variant), 0)
+		}
+	}
+	if findStructByName(objects.ExportedStructs, name) != nil || findInterfaceByName(objects.ExportedInterfaces, name) != nil {
+		return "class " + name
+	}
+	if findAliasByName(objects.ExportedAliases, name) != nil {
+		return "class " + name
+	}
+	for _, exportedType := range objects.ExportedTypes {
+		if exportedType.Name == name {
+			return "class " + name
+		}
+	}
+	for _, constant := range objects.ExportedConstants {
+		if constant.Name == name {
+			return "data " + name
+		}
+	}
+	for _, variable := range objects.ExportedVariables {
+		if variable.Name == name {
+			return "data " + name
+		}
+	}
+	return ""
+}
+
+// callDocstring is the docstring of a routine, followed by the Args:,
+// Returns: and Raises: sections derived from its signature and the Examples:
+// translated from Go. Undocumented routines, such as field accessors, only
+// get their examples.
+func callDocstring(call bridgeCall, returns bool) string {
+	examples := ""
+	if call.Examples != "" {
+		examples = "Examples:\n" + pythonIndent + strings.ReplaceAll(call.Examples, "\n", "\n"+pythonIndent)
+	}
+	if call.Doc == "" {
+		return examples
+	}
+	sections := []string{call.Doc}
+
+	arguments := []string{}
+	for _, argument := range call.Arguments {
+		switch {
+		case argument.Type.hidden():
+		case argument.Type.kind == variadicKind:
+			arguments = append(arguments, fmt.Sprintf("%s*%s (%s)", pythonIndent, argument.Name, argument.Type.element.pythonType()))
+		case argument.Type.kind == optionsKind:
+			for _, option := range argument.Type.options {
+				optionType := "bool"
+				if option.Value != nil {
+					optionType = option.Value.pythonType() + ", optional"
+				}
+				arguments = append(arguments, fmt.Sprintf("%s%s (%s)", pythonIndent, option.Keyword, optionType))
+			}
+		default:
+			arguments = append(arguments, fmt.Sprintf("%s%s (%s)", pythonIndent, argument.Name, argument.Type.pythonType()))
+		}
+	}
+	if len(arguments) > 0 {
+		sections = append(sections, "Args:\n"+strings.Join(arguments, "\n"))
+	}
+	if returns && len(call.Results) > 0 {
+		sections = append(sections, "Returns:\n"+pythonIndent+pythonReturnType(call.Results))
+	}
+	if call.ReturnsError {
+		sections = append(sections, "Raises:\n"+pythonIndent+"GoError: If the Go call returns an error.")
+	}
+	if examples != "" {
+		sections = append(sections, examples)
+	}
+	return strings.Join(sections, "\n\n")
+}
+
+// wrapText wraps the words of a paragraph, indenting the lines after the
+// first one.
+func wrapText(text string, width int, indent string) string {
+	lines := []string{}
+	line := ""
+	for _, word := range strings.Fields(text) {
+		if line != "" && len(line)+1+len(word) > width {
+			lines = append(lines, line)
+			line = ""
+		}
+		if line != "" {
+			line += " "
+		}
+		line += word
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n"+indent)
+}
@@ -0,0 +1,122 @@
+package generator
+
+import (
+	"go/ast"
+	"slices"
+	"strings"
+)
+
+const maxEmbeddingDepth = 8
+
+// promoteEmbedded flattens the fields and methods promoted from embedded
+// structs into the structs embedding them, since the bridge reaches them
+// through the outer struct just like Go selectors do.
+func (context *generatorContext) promoteEmbedded(objects ExportedObjects) ExportedObjects {
+	structs := make([]ExportedStruct, 0, len(objects.ExportedStructs))
+	for _, exportedStruct := range objects.ExportedStructs {
+		structs = append(structs, context.promoteMembers(objects.ExportedStructs, exportedStruct))
+	}
+	objects.ExportedStructs = structs
+	return objects
+}
+
+// embeddedStruct is a struct embedded at some depth, along with its selector
+// from the outer struct and the selectors of the pointers embedded on the way,
+// which are nil in a zero value of the outer struct.
+type embeddedStruct struct {
+	ExportedStruct
+	selector string
+	pointers []string
+}
+
+// promoteMembers follows the Go selector rules: a member of a shallower
+// embedding shadows the deeper ones, and members found more than once at the
+// same depth are ambiguous and not promoted. Members promoted through embedded
+// pointers are recorded in nilEmbeddings, so that the bridge checks them.
+func (context *generatorContext) promoteMembers(structs []ExportedStruct, exportedStruct ExportedStruct) ExportedStruct {
+	taken := map[string]bool{}
+	for _, field := range exportedStruct.Fields {
+		taken[field.Name] = true
+	}
+	for _, method := range exportedStruct.Methods {
+		taken[method.Name] = true
+	}
+
+	promoted := exportedStruct
+	promoted.Fields = append([]ExportedField{}, exportedStruct.Fields...)
+	promoted.Methods = append([]ExportedRoutine{}, exportedStruct.Methods...)
+	level := context.embeddedStructs(structs, embeddedStruct{ExportedStruct: exportedStruct})
+
+	// Embedding through pointers can be cyclic, which the depth bounds.
+	for depth := 0; len(level) > 0 && depth < maxEmbeddingDepth; depth++ {
+		counts := map[string]int{}
+		pointers := map[string][]string{}
+		fields := []ExportedField{}
+		methods := []ExportedRoutine{}
+		next := []embeddedStruct{}
+		for _, embedded := range level {
+			for _, field := range embedded.Fields {
+				counts[field.Name]++
+				pointers[field.Name] = embedded.pointers
+				fields = append(fields, field)
+			}
+			for _, method := range embedded.Methods {
+				counts[method.Name]++
+				pointers[method.Name] = embedded.pointers
+				methods = append(methods, method)
+			}
+			next = append(next, context.embeddedStructs(structs, embedded)...)
+		}
+
+		promote := func(name string) {
+			if len(pointers[name]) > 0 {
+				context.nilEmbeddings[exportedStruct.Name+"."+name] = pointers[name]
+			}
+		}
+		for _, field := range fields {
+			if counts[field.Name] == 1 && !taken[field.Name] && ast.IsExported(field.Name) {
+				promoted.Fields = append(promoted.Fields, field)
+				promote(field.Name)
+			}
+		}
+		for _, method := range methods {
+			if counts[method.Name] == 1 && !taken[method.Name] {
+				promoted.Methods = append(promoted.Methods, method)
+				promote(method.Name)
+			}
+		}
+		for name := range counts {
+			taken[name] = true
+		}
+		level = next
+	}
+
+	return promoted
+}
+
+// embeddedStructs returns the exported structs of the package embedded in a
+// struct, by value or by pointer.
+func (context *generatorContext) embeddedStructs(structs []ExportedStruct, outer embeddedStruct) []embeddedStruct {
+	embedded := []embeddedStruct{}
+	for _, field := range outer.Fields {
+		if !field.Embedded {
+			continue
+		}
+		typeName, pointer := strings.CutPrefix(field.Type, "*")
+		name, local := strings.CutPrefix(typeName, context.exportedPackage.GoPath+".")
+		found := findStructByName(structs, name)
+		if !local || found == nil {
+			continue
+		}
+		selector := field.Name
+		if outer.selector != "" {
+			selector = outer.selector + "." + field.Name
+		}
+		pointers := slices.Clone(outer.pointers)
+		if pointer {
+			pointers = append(pointers, selector)
+		}
+		embedded = append(embedded, embeddedStruct{ExportedStruct: *found, selector: selector, pointers: pointers})
+	}
+	return embedded
+}
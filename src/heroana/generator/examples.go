@@ -0,0 +1,345 @@
+package generator
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/doc"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// InspectExamples reads the Example functions of the _test.go files of a
+// package, both of the package itself and of its external _test package. The
+// package is loaded like InspectPackage does.
+func InspectExamples(directory, packagePath string) ([]ExportedExample, error) {
+	pkg, err := getPackage(directory, packagePath)
+	if err != nil {
+		return nil, fmt.Errorf("error inspecting examples: %w", err)
+	}
+	if len(pkg.GoFiles) == 0 {
+		return nil, nil
+	}
+
+	packageDirectory := filepath.Dir(pkg.GoFiles[0])
+	entries, err := os.ReadDir(packageDirectory)
+	if err != nil {
+		return nil, fmt.Errorf("error inspecting examples: %w", err)
+	}
+
+	fileSet := token.NewFileSet()
+	examples := []ExportedExample{}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(fileSet, filepath.Join(packageDirectory, entry.Name()), nil, parser.ParseComments)
+		if err != nil {
+			return nil, fmt.Errorf("error inspecting examples: %w", err)
+		}
+
+		qualifier := ""
+		if file.Name.Name != pkg.Name {
+			qualifier = importName(file, pkg.PkgPath, pkg.Name)
+		}
+		printer := importName(file, "fmt", "fmt")
+		for _, example := range doc.Examples(file) {
+			examples = append(examples, parseExample(fileSet, example, qualifier, printer))
+		}
+	}
+	return examples, nil
+}
+
+// importName is the name a file refers to an imported package by, or an
+// empty string when the file does not import it.
+func importName(file *ast.File, importPath, packageName string) string {
+	for _, spec := range file.Imports {
+		if path, _ := strconv.Unquote(spec.Path.Value); path != importPath {
+			continue
+		}
+		if spec.Name != nil {
+			return spec.Name.Name
+		}
+		return packageName
+	}
+	return ""
+}
+
+func parseExample(fileSet *token.FileSet, example *doc.Example, qualifier, printer string) ExportedExample {
+	exportedExample := ExportedExample{
+		Name:      example.Name,
+		Suffix:    example.Suffix,
+		Output:    example.Output,
+		HasOutput: example.Output != "" || example.EmptyOutput,
+		Unordered: example.Unordered,
+	}
+
+	body, ok := example.Code.(*ast.BlockStmt)
+	if !ok {
+		exportedExample.Problem = "the example is a whole file"
+		return exportedExample
+	}
+	for _, statement := range body.List {
+		call, problem := parseExampleStatement(statement, qualifier, printer)
+		if problem != "" {
+			exportedExample.Calls = nil
+			exportedExample.Problem = fmt.Sprintf("line %d: %s", fileSet.Position(statement.Pos()).Line, problem)
+			return exportedExample
+		}
+		exportedExample.Calls = append(exportedExample.Calls, call)
+	}
+	return exportedExample
+}
+
+// parseExampleStatement reads a statement calling a package function with
+// literal arguments, optionally printed by fmt.Println.
+func parseExampleStatement(statement ast.Stmt, qualifier, printer string) (exampleCall ExampleCall, problem string) {
+	expression, ok := statement.(*ast.ExprStmt)
+	if !ok {
+		return exampleCall, "only calls are translated"
+	}
+	call, ok := expression.X.(*ast.CallExpr)
+	if !ok {
+		return exampleCall, "only calls are translated"
+	}
+
+	if selector, ok := call.Fun.(*ast.SelectorExpr); ok && printer != "" && isIdent(selector.X, printer) && selector.Sel.Name == "Println" {
+		if len(call.Args) != 1 {
+			return exampleCall, "fmt.Println must print a single call"
+		}
+		if call, ok = call.Args[0].(*ast.CallExpr); !ok {
+			return exampleCall, "fmt.Println must print a single call"
+		}
+		exampleCall.Printed = true
+	}
+
+	exampleCall.Function = packageFunction(call.Fun, qualifier)
+	if exampleCall.Function == "" {
+		return exampleCall, fmt.Sprintf("%s is not a function of the package", types.ExprString(call.Fun))
+	}
+	if call.Ellipsis.IsValid() {
+		return exampleCall, "spread variadic arguments are not translated"
+	}
+	for _, argument := range call.Args {
+		if !isLiteral(argument) {
+			return exampleCall, fmt.Sprintf("argument %s is not a literal", types.ExprString(argument))
+		}
+		exampleCall.Arguments = append(exampleCall.Arguments, types.ExprString(argument))
+	}
+	return exampleCall, ""
+}
+
+// packageFunction is the name of the exported package function called, or
+// an empty string when the callee is something else.
+func packageFunction(function ast.Expr, qualifier string) string {
+	if qualifier == "" {
+		if ident, ok := function.(*ast.Ident); ok && ident.IsExported() {
+			return ident.Name
+		}
+		return ""
+	}
+	if selector, ok := function.(*ast.SelectorExpr); ok && isIdent(selector.X, qualifier) && selector.Sel.IsExported() {
+		return selector.Sel.Name
+	}
+	return ""
+}
+
+func isIdent(expression ast.Expr, name string) bool {
+	ident, ok := expression.(*ast.Ident)
+	return ok && ident.Name == name
+}
+
+func isLiteral(expression ast.Expr) bool {
+	switch expression := expression.(type) {
+	case *ast.BasicLit:
+		return true
+	case *ast.Ident:
+		return expression.Name == "true" || expression.Name == "false"
+	case *ast.UnaryExpr:
+		_, ok := expression.X.(*ast.BasicLit)
+		return ok && (expression.Op == token.SUB || expression.Op == token.ADD)
+	case *ast.ParenExpr:
+		return isLiteral(expression.X)
+	}
+	return false
+}
+
+// doctests translates the examples of the exported functions into doctest
+// snippets, keyed by function name. Examples that cannot be translated are
+// reported and left out.
+func (context *generatorContext) doctests() map[string]string {
+	doctests := map[string]string{}
+	for _, example := range context.objects.ExportedExamples {
+		name := "Example" + example.Name
+		if example.Suffix != "" {
+			name += "_" + example.Suffix
+		}
+		snippet, problem := context.doctest(example)
+		if problem != "" {
+			log.Printf("Skipping example %s: %s", name, problem)
+			continue
+		}
+		if doctests[example.Name] != "" {
+			snippet = doctests[example.Name] + "\n\n" + snippet
+		}
+		doctests[example.Name] = snippet
+	}
+	return doctests
+}
+
+func (context *generatorContext) doctest(example ExportedExample) (snippet string, problem string) {
+	if example.Problem != "" {
+		return "", example.Problem
+	}
+	if !context.exportedFunction(example.Name) {
+		return "", "only examples of exported functions are translated"
+	}
+	if example.Unordered {
+		return "", "unordered output cannot be checked by doctest"
+	}
+	if strings.Contains(example.Output, "\t") {
+		return "", "doctest expands the tabs of the output"
+	}
+
+	output := []string{}
+	if trimmed := strings.TrimRight(example.Output, "\n"); trimmed != "" {
+		output = strings.Split(trimmed, "\n")
+	}
+	printed := 0
+	for _, call := range example.Calls {
+		if call.Printed {
+			printed++
+		}
+	}
+	if printed > 0 && !example.HasOutput {
+		return "", "it has no Output comment"
+	}
+	if printed != len(output) {
+		return "", fmt.Sprintf("%d printed calls do not match %d output lines", printed, len(output))
+	}
+
+	lines := []string{}
+	for _, call := range example.Calls {
+		line, problem := context.doctestCall(call)
+		if problem != "" {
+			return "", problem
+		}
+		lines = append(lines, ">>> "+line)
+		if call.Printed {
+			result := strings.TrimRight(output[0], " ")
+			if result == "" {
+				result = "<BLANKLINE>"
+			}
+			lines = append(lines, result)
+			output = output[1:]
+		}
+	}
+	return strings.Join(lines, "\n"), ""
+}
+
+// exportedFunction reports whether a name is exported to Python as a plain
+// function, rather than folded into a class as a constructor.
+func (context *generatorContext) exportedFunction(name string) bool {
+	function := findFunctionByName(context.objects.ExportedFunctions, name)
+	if function == nil {
+		return false
+	}
+	_, _, constructor := context.constructorOf(*function)
+	return !constructor
+}
+
+// doctestCall renders a call as a Python statement. Only ints and strings
+// are printed, as Python prints other values differently than Go does.
+func (context *generatorContext) doctestCall(exampleCall ExampleCall) (line string, problem string) {
+	if !context.exportedFunction(exampleCall.Function) {
+		return "", fmt.Sprintf("%s is not exported as a Python function", exampleCall.Function)
+	}
+	function := findFunctionByName(context.objects.ExportedFunctions, exampleCall.Function)
+	call, ok := context.resolveRoutine(*function, context.symbol(function.Name))
+	if !ok {
+		return "", fmt.Sprintf("%s has unsupported types", function.Name)
+	}
+
+	parameters := []bridgeType{}
+	for _, argument := range call.Arguments {
+		if !argument.Type.hidden() {
+			parameters = append(parameters, argument.Type)
+		}
+	}
+	arguments := make([]string, 0, len(exampleCall.Arguments))
+	for index, literal := range exampleCall.Arguments {
+		var parameter bridgeType
+		switch {
+		case index < len(parameters) && parameters[index].kind != variadicKind:
+			parameter = parameters[index]
+		case len(parameters) > 0 && parameters[len(parameters)-1].kind == variadicKind:
+			parameter = *parameters[len(parameters)-1].element
+		default:
+			return "", fmt.Sprintf("%s takes %d arguments", function.Name, len(parameters))
+		}
+		argument, ok := pythonLiteral(literal, parameter)
+		if !ok {
+			return "", fmt.Sprintf("argument %s of %s is not translated to %s", literal, function.Name, parameter.pythonType())
+		}
+		arguments = append(arguments, argument)
+	}
+
+	if call.Async {
+		return "", fmt.Sprintf("%s is a coroutine", function.Name)
+	}
+	line = fmt.Sprintf("%s(%s)", call.Name, strings.Join(arguments, ", "))
+	if !exampleCall.Printed {
+		if len(call.Results) > 0 {
+			line = "_ = " + line
+		}
+		return line, ""
+	}
+	if len(call.Results) != 1 || call.ReturnsError {
+		return "", fmt.Sprintf("%s returns several values", function.Name)
+	}
+	switch call.Results[0].kind {
+	case intKind, uintKind:
+		return line, ""
+	case stringKind:
+		return "print(" + line + ")", ""
+	}
+	return "", fmt.Sprintf("%s returns a %s, printed differently by Python", function.Name, call.Results[0].pythonType())
+}
+
+// pythonLiteral converts a Go literal to the Python literal of a parameter.
+func pythonLiteral(literal string, parameter bridgeType) (string, bool) {
+	value, err := types.Eval(token.NewFileSet(), nil, token.NoPos, literal)
+	if err != nil || value.Value == nil {
+		return "", false
+	}
+
+	switch parameter.kind {
+	case intKind, uintKind:
+		if integer := constant.ToInt(value.Value); integer.Kind() == constant.Int {
+			return integer.ExactString(), true
+		}
+	case floatKind:
+		if float := constant.ToFloat(value.Value); float.Kind() == constant.Float || float.Kind() == constant.Int {
+			number, _ := constant.Float64Val(float)
+			return floatLiteral(number), true
+		}
+	case boolKind:
+		if value.Value.Kind() == constant.Bool {
+			if constant.BoolVal(value.Value) {
+				return "True", true
+			}
+			return "False", true
+		}
+	case stringKind:
+		if value.Value.Kind() == constant.String {
+			return strconv.Quote(constant.StringVal(value.Value)), true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,26 @@
+// Package directives holds misplaced and malformed directives.
+package directives
+
+// melo:ignore
+type Renamed struct {
+	// melo:name class
+	Field string
+}
+
+// melo:unknown
+func Unknown() {}
+
+// melo:readonly
+func NotAVariable() {}
+
+// melo:name first second
+func TwoNames() {}
+
+// melo:asgi
+type Handler interface {
+	// melo:ignore
+	Serve()
+}
+
+// melo:readonly now
+var Value int
@@ -0,0 +1,27 @@
+package fixtures_test
+
+import (
+	"fmt"
+
+	"github.com/EdmilsonRodrigues/melo-project/src/melo/generator/fixtures"
+)
+
+func ExampleSumTwoNumbers() {
+	fmt.Println(fixtures.SumTwoNumbers(1, -2))
+	fmt.Println(fixtures.SumTwoNumbers(0x10, 'a'))
+	// Output:
+	// -1
+	// 113
+}
+
+func ExampleSumNumbers() {
+	fixtures.SumNumbers(1, 2)
+	fmt.Println(fixtures.SumNumbers(1, 2, 3))
+	// Output: 6
+}
+
+func ExampleMyStruct() {
+	value := fixtures.MyStruct{Name: "hello"}
+	fmt.Println(value.Name)
+	// Output: hello
+}
@@ -2,24 +2,51 @@
 
 package fixtures
 
+import "net/http"
 
 // Go doc for my constant
 const MyConst = "hello"
 
+// Go doc for my rune constant
+const MyRuneConst = 'a'
+
+// Go doc for my grouped constants
+const (
+	// Go doc for my first grouped constant
+	MyFirstGroupedConst  = 1
+	MySecondGroupedConst = 2 // Go doc for my second grouped constant
+	MyThirdGroupedConst  = 3
+)
+
 // Go doc for my type
 type MyType string
 
 // Go doc for my variable
 var MyVar = "world"
 
+// Go doc for my read-only variable
+//
+// melo:readonly
+var MyReadOnlyVar int
+
 // Go doc for my struct
 type MyStruct struct {
 	// Go doc for my field
 	Name string
 }
 
+// Go doc for my alias
+type MyAlias = MyStruct
+
+// Go doc for my embedding struct
+type MyEmbeddingStruct struct {
+	MyStruct
+	Extra int `json:"extra,omitempty" melo:"readonly"` // Go doc for my extra field
+}
+
 // Go doc for my interface
 type MyInterface interface {
+	// Go doc for my method
 	SayHello(name string) string
 }
 
@@ -33,8 +60,44 @@ func CanSumTwoNumbers(a, b int) (sum int, err error) {
 	return a + b, nil
 }
 
+// Go doc for my variadic function
+func SumNumbers(numbers ...int) (sum int) {
+	for _, number := range numbers {
+		sum += number
+	}
+	return
+}
+
+// Go doc for my handler
+//
+// melo:asgi
+func MyHandler() http.Handler {
+	return http.NotFoundHandler()
+}
+
+// Go doc for my generic function
+//
+// melo:instantiate Identity[int]
+func Identity[T any](value T) T {
+	return value
+}
+
 // Go doc for my method
 func (s MyStruct) CanSumTwoNumbers2(a, b int) (sum int, err error) {
 	return a + b, nil
 }
 
+// Go doc for my ignored function
+//
+// melo:ignore
+func IgnoredFunction() {}
+
+// Go doc for my struct with an ignored field
+type MyIgnoringStruct struct {
+	// Go doc for my renamed field
+	//
+	// melo:name renamed
+	Kept string
+	// melo:ignore
+	Ignored string
+}
@@ -0,0 +1,251 @@
+package generator
+
+import (
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/EdmilsonRodrigues/melo-project/src/melo/files"
+)
+
+// instantiateDirective lists the instantiations of a generic function or
+// type to export, such as "// melo:instantiate Map[int] Map[string]".
+const instantiateDirective = files.InstantiateDirective
+
+// genericInstance is a concrete instantiation of a generic function or type,
+// exported under a Python friendly name such as Map_int for Map[int].
+type genericInstance struct {
+	Name   string
+	GoName string
+}
+
+// genericFunction is a generic function along with the names of its
+// instantiations, dispatched from Python by the type of the arguments.
+type genericFunction struct {
+	Generic   ExportedRoutine
+	Instances []string
+}
+
+// instantiateGenerics replaces the generic functions and structs by the
+// instantiations listed in their directives, renaming the references to the
+// instantiated types. Generics without instantiation are reported and left
+// out, since cgo cannot export them.
+func (context *generatorContext) instantiateGenerics(objects ExportedObjects) ExportedObjects {
+	instantiated := objects
+	instantiated.ExportedStructs = nil
+	instantiated.ExportedFunctions = nil
+	renames := map[string]string{}
+
+	for _, exportedStruct := range objects.ExportedStructs {
+		if len(exportedStruct.TypeParameters) == 0 {
+			instantiated.ExportedStructs = append(instantiated.ExportedStructs, exportedStruct)
+			continue
+		}
+		for _, arguments := range context.instantiations("type", exportedStruct.Name, exportedStruct.TypeParameters, exportedStruct.Directives) {
+			instance := context.addInstance(exportedStruct.Name, arguments)
+			renames[context.qualifiedInstance(exportedStruct.Name, arguments)] = context.exportedPackage.GoPath + "." + instance.Name
+
+			concrete := exportedStruct
+			concrete.Name = instance.Name
+			concrete.TypeParameters = nil
+			concrete.Fields = make([]ExportedField, 0, len(exportedStruct.Fields))
+			for _, field := range exportedStruct.Fields {
+				field.Type = context.substitute(field.Type, exportedStruct.TypeParameters, arguments)
+				concrete.Fields = append(concrete.Fields, field)
+			}
+			concrete.Methods = make([]ExportedRoutine, 0, len(exportedStruct.Methods))
+			for _, method := range exportedStruct.Methods {
+				concrete.Methods = append(concrete.Methods, context.instantiateRoutine(method, method.TypeParameters, arguments))
+			}
+			instantiated.ExportedStructs = append(instantiated.ExportedStructs, concrete)
+		}
+	}
+
+	for _, function := range objects.ExportedFunctions {
+		if len(function.TypeParameters) == 0 {
+			instantiated.ExportedFunctions = append(instantiated.ExportedFunctions, function)
+			continue
+		}
+		generic := genericFunction{Generic: function}
+		for _, arguments := range context.instantiations("function", function.Name, function.TypeParameters, function.Directives) {
+			instance := context.addInstance(function.Name, arguments)
+			concrete := context.instantiateRoutine(function, function.TypeParameters, arguments)
+			concrete.Name = instance.Name
+			instantiated.ExportedFunctions = append(instantiated.ExportedFunctions, concrete)
+			generic.Instances = append(generic.Instances, instance.Name)
+		}
+		if len(generic.Instances) > 0 {
+			context.generics = append(context.generics, generic)
+		}
+	}
+
+	for index := range instantiated.ExportedStructs {
+		exportedStruct := &instantiated.ExportedStructs[index]
+		for fieldIndex := range exportedStruct.Fields {
+			exportedStruct.Fields[fieldIndex].Type = renameTypes(exportedStruct.Fields[fieldIndex].Type, renames)
+		}
+		for methodIndex := range exportedStruct.Methods {
+			exportedStruct.Methods[methodIndex] = renameRoutineTypes(exportedStruct.Methods[methodIndex], renames)
+		}
+	}
+	for index := range instantiated.ExportedFunctions {
+		instantiated.ExportedFunctions[index] = renameRoutineTypes(instantiated.ExportedFunctions[index], renames)
+	}
+
+	return instantiated
+}
+
+// instantiations returns the type arguments of every instantiation of a
+// generic listed in its directives.
+func (context *generatorContext) instantiations(kind, name string, typeParameters, directives []string) [][]string {
+	instantiations := [][]string{}
+	for _, directive := range directives {
+		values, ok := strings.CutPrefix(directive, instantiateDirective+" ")
+		if !ok {
+			continue
+		}
+		for _, value := range splitInstantiations(values) {
+			instanceName, arguments, ok := strings.Cut(strings.TrimSuffix(value, "]"), "[")
+			if !ok || instanceName != name {
+				log.Printf("Skipping instantiation %s of generic %s %s: expected %s[...]", value, kind, name, name)
+				continue
+			}
+			typeArguments := strings.Split(arguments, ",")
+			for index := range typeArguments {
+				typeArguments[index] = strings.TrimSpace(typeArguments[index])
+			}
+			if len(typeArguments) != len(typeParameters) {
+				log.Printf("Skipping instantiation %s of generic %s %s: expected %d type arguments", value, kind, name, len(typeParameters))
+				continue
+			}
+			instantiations = append(instantiations, typeArguments)
+		}
+	}
+
+	if len(instantiations) == 0 {
+		log.Printf("Skipping generic %s %s: add a \"// melo:%s %s[...]\" directive to export its instantiations", kind, name, instantiateDirective, name)
+	}
+	return instantiations
+}
+
+func (context *generatorContext) addInstance(name string, arguments []string) genericInstance {
+	names := []string{name}
+	goArguments := make([]string, 0, len(arguments))
+	for _, argument := range arguments {
+		names = append(names, sanitizeIdentifier(strings.NewReplacer("*", "ptr", "[]", "slice").Replace(argument)))
+		goArguments = append(goArguments, qualifyTypeArgument(argument, context.alias))
+	}
+
+	instance := genericInstance{
+		Name:   strings.Join(names, "_"),
+		GoName: name + "[" + strings.Join(goArguments, ", ") + "]",
+	}
+	context.instances[instance.Name] = instance
+	return instance
+}
+
+// qualifiedInstance is the type string of an instantiated type, as reported
+// by the inspector.
+func (context *generatorContext) qualifiedInstance(name string, arguments []string) string {
+	qualified := make([]string, 0, len(arguments))
+	for _, argument := range arguments {
+		qualified = append(qualified, qualifyTypeArgument(argument, context.exportedPackage.GoPath))
+	}
+	return context.exportedPackage.GoPath + "." + name + "[" + strings.Join(qualified, ", ") + "]"
+}
+
+func (context *generatorContext) instantiateRoutine(routine ExportedRoutine, typeParameters, arguments []string) ExportedRoutine {
+	concrete := routine
+	concrete.TypeParameters = nil
+	concrete.Arguments = make([]ExportedArgument, 0, len(routine.Arguments))
+	for _, argument := range routine.Arguments {
+		argument.Type = context.substitute(argument.Type, typeParameters, arguments)
+		concrete.Arguments = append(concrete.Arguments, argument)
+	}
+	concrete.ReturnTypes = make([]string, 0, len(routine.ReturnTypes))
+	for _, returnType := range routine.ReturnTypes {
+		concrete.ReturnTypes = append(concrete.ReturnTypes, context.substitute(returnType, typeParameters, arguments))
+	}
+	return concrete
+}
+
+// substitute replaces the type parameters of a type string by the type
+// arguments of an instantiation.
+func (context *generatorContext) substitute(typeName string, typeParameters, arguments []string) string {
+	for index, typeParameter := range typeParameters {
+		pattern := regexp.MustCompile(`(^|[^\w./])` + regexp.QuoteMeta(typeParameter) + `\b`)
+		typeName = pattern.ReplaceAllString(typeName, "${1}"+qualifyTypeArgument(arguments[index], context.exportedPackage.GoPath))
+	}
+	return typeName
+}
+
+// mentionsTypeParameter reports whether a type string of a generic routine
+// depends on one of its type parameters.
+func mentionsTypeParameter(typeName string, typeParameters []string) bool {
+	for _, typeParameter := range typeParameters {
+		if regexp.MustCompile(`(^|[^\w./])` + regexp.QuoteMeta(typeParameter) + `\b`).MatchString(typeName) {
+			return true
+		}
+	}
+	return false
+}
+
+// qualifyTypeArgument prefixes the exported types of the package named in a
+// directive, such as *Person, with the package path or alias.
+func qualifyTypeArgument(argument, qualifier string) string {
+	return localTypePattern.ReplaceAllString(argument, "${1}"+qualifier+".${2}")
+}
+
+var localTypePattern = regexp.MustCompile(`(^|[^\w.])([A-Z]\w*)`)
+
+// splitInstantiations splits the instantiations of a directive on the spaces
+// outside of brackets, so that Pair[string, int] stays whole.
+func splitInstantiations(values string) []string {
+	instantiations := []string{}
+	depth, start := 0, 0
+	for index, character := range values + " " {
+		switch {
+		case character == '[':
+			depth++
+		case character == ']':
+			depth--
+		case character == ' ' && depth == 0:
+			if value := strings.TrimSpace(values[start:index]); value != "" {
+				instantiations = append(instantiations, value)
+			}
+			start = index
+		}
+	}
+	return instantiations
+}
+
+func renameRoutineTypes(routine ExportedRoutine, renames map[string]string) ExportedRoutine {
+	arguments := make([]ExportedArgument, 0, len(routine.Arguments))
+	for _, argument := range routine.Arguments {
+		argument.Type = renameTypes(argument.Type, renames)
+		arguments = append(arguments, argument)
+	}
+	returnTypes := make([]string, 0, len(routine.ReturnTypes))
+	for _, returnType := range routine.ReturnTypes {
+		returnTypes = append(returnTypes, renameTypes(returnType, renames))
+	}
+	routine.Arguments = arguments
+	routine.ReturnTypes = returnTypes
+	return routine
+}
+
+func renameTypes(typeName string, renames map[string]string) string {
+	for instantiated, renamed := range renames {
+		typeName = strings.ReplaceAll(typeName, instantiated, renamed)
+	}
+	return typeName
+}
+
+// goName is the Go expression naming an exported object, which differs from
+// its exported name for instantiations of generics.
+func (context *generatorContext) goName(name string) string {
+	if instance, ok := context.instances[name]; ok {
+		return instance.GoName
+	}
+	return name
+}
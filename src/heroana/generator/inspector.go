@@ -3,22 +3,34 @@ package generator
 import (
 	"fmt"
 	"go/ast"
+	"go/constant"
+	"go/token"
 	"go/types"
 	"log"
+	"math/big"
+	"strconv"
 	"strings"
 
 	"golang.org/x/tools/go/packages"
 )
 
-func InspectPackage(packagePath string) (exportedObjects ExportedObjects, err error) {
-	pkg, err := getPackage(packagePath)
+// InspectPackage reads the exported declarations of a package, loaded by
+// import path from the module in directory, the current one when empty.
+func InspectPackage(directory, packagePath string) (exportedObjects ExportedObjects, err error) {
+	pkg, err := getPackage(directory, packagePath)
 	if err != nil {
 		err = fmt.Errorf("error inspecting package: %w", err)
 		return
 	}
 
+	if err = checkDirectives(pkg.Fset, pkg.Syntax); err != nil {
+		err = fmt.Errorf("error inspecting package: %w", err)
+		return
+	}
+
+	fieldDocs := collectFieldDocs(pkg.Syntax)
 	for _, file := range pkg.Syntax {
-		inspectAbstractSyntaxTree(file, pkg, &exportedObjects)
+		inspectAbstractSyntaxTree(file, pkg, fieldDocs, &exportedObjects)
 	}
 
 	return
@@ -66,8 +78,9 @@ func InspectPackage(packagePath string) (exportedObjects ExportedObjects, err er
 	// return nil
 }
 
-func getPackage(packagePath string) (*packages.Package, error) {
+func getPackage(directory, packagePath string) (*packages.Package, error) {
 	cfg := &packages.Config{
+		Dir: directory,
 		Mode: packages.NeedName | packages.NeedFiles | packages.NeedCompiledGoFiles |
 			packages.NeedImports | packages.NeedDeps | packages.NeedExportFile |
 			packages.NeedTypes | packages.NeedSyntax | packages.NeedTypesInfo | packages.NeedModule,
@@ -95,7 +108,7 @@ func getPackage(packagePath string) (*packages.Package, error) {
 	return pkg, nil
 }
 
-func inspectAbstractSyntaxTree(file *ast.File, pkg *packages.Package, exportedObjects *ExportedObjects) {
+func inspectAbstractSyntaxTree(file *ast.File, pkg *packages.Package, fieldDocs map[token.Pos]*ast.CommentGroup, exportedObjects *ExportedObjects) {
 	methods := make(map[string][]*ExportedRoutine)
 	ast.Inspect(file, func(node ast.Node) bool {
 		switch declaration := node.(type) {
@@ -104,7 +117,11 @@ func inspectAbstractSyntaxTree(file *ast.File, pkg *packages.Package, exportedOb
 				return true
 			}
 
-			if exportedRoutine, receiver := parseRoutineDeclaration(pkg, declaration); receiver == "" {
+			exportedRoutine, receiver := parseRoutineDeclaration(pkg, declaration)
+			if parseDeclarationDirectives(exportedRoutine.Directives).Ignore {
+				return true
+			}
+			if receiver == "" {
 				exportedObjects.ExportedFunctions = append(exportedObjects.ExportedFunctions, exportedRoutine)
 			} else {
 				methods[receiver] = append(methods[receiver], &exportedRoutine)
@@ -114,6 +131,10 @@ func inspectAbstractSyntaxTree(file *ast.File, pkg *packages.Package, exportedOb
 			for _, spec := range declaration.Specs {
 				switch specification := spec.(type) {
 				case *ast.ValueSpec: // Var or Const
+					doc, directives := parseDirectives(specDoc(declaration, specification.Doc, specification.Comment))
+					if parseDeclarationDirectives(directives).Ignore {
+						continue
+					}
 					for index, name := range specification.Names {
 						if !ast.IsExported(name.Name) {
 							continue
@@ -123,22 +144,23 @@ func inspectAbstractSyntaxTree(file *ast.File, pkg *packages.Package, exportedOb
 						if variable == nil {
 							continue
 						}
-						typeName := strings.TrimLeft(variable.Type().String(), "untyped ")
+						typeName := strings.TrimPrefix(variable.Type().String(), "untyped ")
 
 						switch name.Obj.Kind {
 						case ast.Con:
 							exportedObjects.ExportedConstants = append(exportedObjects.ExportedConstants, ExportedConstant{
 								Name:  name.Name,
 								Type:  typeName,
-								Value: parseVariableValue(specification.Values[index].(*ast.BasicLit), typeName),
-								Doc:   specification.Doc.Text(), // TODO: Find a way to get the doc
+								Value: parseConstantValue(variable.(*types.Const)),
+								Doc:   doc,
 							})
 						case ast.Var:
 							exportedObjects.ExportedVariables = append(exportedObjects.ExportedVariables, ExportedVariable{
-								Name:  name.Name,
-								Type:  typeName,
-								Value: parseVariableValue(specification.Values[index].(*ast.BasicLit), typeName),
-								Doc:   specification.Doc.Text(), // TODO: Find a way to get the doc
+								Name:       name.Name,
+								Type:       typeName,
+								Value:      parseValue(specification, index, typeName),
+								Doc:        doc,
+								Directives: directives,
 							})
 						}
 					}
@@ -151,18 +173,33 @@ func inspectAbstractSyntaxTree(file *ast.File, pkg *packages.Package, exportedOb
 						continue
 					}
 
+					comments := specDoc(declaration, specification.Doc, specification.Comment)
+					doc, directives := parseDirectives(comments)
+					if parseDeclarationDirectives(directives).Ignore {
+						continue
+					}
+
+					if specification.Assign.IsValid() { // Alias
+						exportedObjects.ExportedAliases = append(exportedObjects.ExportedAliases, ExportedAlias{
+							Name: specification.Name.Name,
+							Type: types.Unalias(object.Type()).String(),
+							Doc:  doc,
+						})
+						continue
+					}
+
 					switch underlying := object.Type().Underlying().(type) {
 					case *types.Struct:
-						exportedStruct := parseExportedStruct(underlying, specification)
+						exportedStruct := parseExportedStruct(underlying, specification, comments, fieldDocs)
 						exportedObjects.ExportedStructs = append(exportedObjects.ExportedStructs, exportedStruct)
 					case *types.Interface:
-						exportedInterface := parseExportedInterface(underlying, specification)
+						exportedInterface := parseExportedInterface(underlying, specification, doc, fieldDocs)
 						exportedObjects.ExportedInterfaces = append(exportedObjects.ExportedInterfaces, exportedInterface)
 					default:
 						exportedObjects.ExportedTypes = append(exportedObjects.ExportedTypes, ExportedType{
 							Name: specification.Name.Name,
 							Type: underlying.String(),
-							Doc:  specification.Doc.Text(), // TODO: Find a way to get the doc
+							Doc:  doc,
 						})
 					}
 
@@ -187,27 +224,132 @@ func inspectAbstractSyntaxTree(file *ast.File, pkg *packages.Package, exportedOb
 func parseRoutineDeclaration(pkg *packages.Package, declaration *ast.FuncDecl) (exportedRoutine ExportedRoutine, receiver string) {
 	signature := pkg.TypesInfo.Defs[declaration.Name].Type().(*types.Signature)
 
+	typeParameters := signature.TypeParams()
 	if signature.Recv() != nil { // Method
 		splittedSignature := strings.Split(signature.Recv().Type().String(), ".")
-		receiver = splittedSignature[len(splittedSignature)-1]
+		receiver, _, _ = strings.Cut(splittedSignature[len(splittedSignature)-1], "[")
+		typeParameters = signature.RecvTypeParams()
 	}
 
+	doc, directives := parseDirectives(declaration.Doc)
 	return ExportedRoutine{
-		Name:        declaration.Name.Name,
-		Arguments:   parseArguments(signature.Params()),
-		ReturnTypes: parseReturnTypes(signature.Results()),
-		Doc:         strings.TrimRight(declaration.Doc.Text(), "\n"),
+		Name:           declaration.Name.Name,
+		Arguments:      parseArguments(signature),
+		ReturnTypes:    parseReturnTypes(signature.Results()),
+		Doc:            doc,
+		Directives:     directives,
+		TypeParameters: parseTypeParameters(typeParameters),
 	}, receiver
 
 }
 
-func parseArguments(arguments *types.Tuple) []ExportedArgument {
+// parseDirectives splits the "// melo:" directives, such as "// melo:asgi",
+// out of a doc comment.
+func parseDirectives(comments *ast.CommentGroup) (doc string, directives []string) {
+	lines := []string{}
+	for _, line := range strings.Split(comments.Text(), "\n") {
+		if directive, ok := strings.CutPrefix(line, "melo:"); ok {
+			directives = append(directives, strings.TrimSpace(directive))
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return strings.TrimRight(strings.Join(lines, "\n"), "\n"), directives
+}
+
+// specDoc picks the doc comment of a const, var or type specification: its
+// own doc, the doc of an ungrouped declaration, its trailing comment, and
+// last the doc of the group it belongs to.
+func specDoc(declaration *ast.GenDecl, doc, comment *ast.CommentGroup) *ast.CommentGroup {
+	switch {
+	case doc != nil:
+		return doc
+	case declaration.Doc != nil && !declaration.Lparen.IsValid():
+		return declaration.Doc
+	case comment != nil:
+		return comment
+	}
+	return declaration.Doc
+}
+
+// collectFieldDocs maps the position of every struct field and interface
+// method name to its doc comment, or to its trailing comment when it has no
+// doc. Embedded fields are keyed by the name of their type, which is where
+// go/types positions them.
+func collectFieldDocs(files []*ast.File) map[token.Pos]*ast.CommentGroup {
+	fieldDocs := make(map[token.Pos]*ast.CommentGroup)
+	for _, file := range files {
+		ast.Inspect(file, func(node ast.Node) bool {
+			var fields *ast.FieldList
+			switch node := node.(type) {
+			case *ast.StructType:
+				fields = node.Fields
+			case *ast.InterfaceType:
+				fields = node.Methods
+			default:
+				return true
+			}
+			for _, field := range fields.List {
+				doc := field.Doc
+				if doc == nil {
+					doc = field.Comment
+				}
+				if doc == nil {
+					continue
+				}
+				for _, name := range field.Names {
+					fieldDocs[name.Pos()] = doc
+				}
+				if len(field.Names) == 0 {
+					fieldDocs[embeddedPos(field.Type)] = doc
+				}
+			}
+			return true
+		})
+	}
+	return fieldDocs
+}
+
+// embeddedPos is the position of the type name of an embedded field.
+func embeddedPos(expression ast.Expr) token.Pos {
+	for {
+		switch typed := expression.(type) {
+		case *ast.StarExpr:
+			expression = typed.X
+		case *ast.ParenExpr:
+			expression = typed.X
+		case *ast.IndexExpr:
+			expression = typed.X
+		case *ast.IndexListExpr:
+			expression = typed.X
+		case *ast.SelectorExpr:
+			return typed.Sel.Pos()
+		default:
+			return expression.Pos()
+		}
+	}
+}
+
+func parseTypeParameters(typeParameters *types.TypeParamList) []string {
+	if typeParameters.Len() == 0 {
+		return nil
+	}
+	names := make([]string, 0, typeParameters.Len())
+	for typeParameter := range typeParameters.TypeParams() {
+		names = append(names, typeParameter.Obj().Name())
+	}
+	return names
+}
+
+func parseArguments(signature *types.Signature) []ExportedArgument {
+	arguments := signature.Params()
 	exportedArguments := make([]ExportedArgument, 0, arguments.Len())
 	for index := range arguments.Len() {
 		argument := arguments.At(index)
 		exportedArguments = append(exportedArguments, ExportedArgument{
-			Name: argument.Name(),
-			Type: argument.Type().String(),
+			Name:     argument.Name(),
+			Type:     argument.Type().String(),
+			Variadic: signature.Variadic() && index == arguments.Len()-1,
 		})
 	}
 	return exportedArguments
@@ -222,6 +364,50 @@ func parseReturnTypes(results *types.Tuple) []string {
 	return returnTypes
 }
 
+// parseConstantValue returns the exact value of a constant as a string, bool,
+// rune, int64, *big.Int when overflowing int64, float64 or complex128.
+func parseConstantValue(object *types.Const) any {
+	value := object.Val()
+	switch value.Kind() {
+	case constant.String:
+		return constant.StringVal(value)
+	case constant.Bool:
+		return constant.BoolVal(value)
+	case constant.Int:
+		if basic, ok := object.Type().(*types.Basic); ok && (basic.Kind() == types.UntypedRune || basic.Name() == "rune") {
+			if character, exact := constant.Int64Val(value); exact {
+				return rune(character)
+			}
+		}
+		if integer, exact := constant.Int64Val(value); exact {
+			return integer
+		}
+		integer, _ := new(big.Int).SetString(value.ExactString(), 10)
+		return integer
+	case constant.Float:
+		float, _ := constant.Float64Val(value)
+		return float
+	case constant.Complex:
+		real, _ := constant.Float64Val(constant.Real(value))
+		imaginary, _ := constant.Float64Val(constant.Imag(value))
+		return complex(real, imaginary)
+	}
+	return nil
+}
+
+// parseValue returns the literal value of a constant or variable, or nil when
+// it is declared without value or with an expression other than a literal.
+func parseValue(specification *ast.ValueSpec, index int, typeName string) any {
+	if index >= len(specification.Values) {
+		return nil
+	}
+	literal, ok := specification.Values[index].(*ast.BasicLit)
+	if !ok {
+		return nil
+	}
+	return parseVariableValue(literal, typeName)
+}
+
 func parseVariableValue(value *ast.BasicLit, typeName string) string {
 	if typeName == "string" {
 		return strings.Trim(value.Value, "\"")
@@ -229,54 +415,106 @@ func parseVariableValue(value *ast.BasicLit, typeName string) string {
 	return value.Value
 }
 
-func parseExportedStruct(structType *types.Struct, specification *ast.TypeSpec) ExportedStruct {
+func parseExportedStruct(structType *types.Struct, specification *ast.TypeSpec, comments *ast.CommentGroup, fieldDocs map[token.Pos]*ast.CommentGroup) ExportedStruct {
+	doc, directives := parseDirectives(comments)
+
+	typeParameters := []string(nil)
+	if specification.TypeParams != nil {
+		for _, field := range specification.TypeParams.List {
+			for _, name := range field.Names {
+				typeParameters = append(typeParameters, name.Name)
+			}
+		}
+	}
+
 	return ExportedStruct{
-		Name:   specification.Name.Name,
-		Fields: parseStructFields(structType),
-		Doc:    strings.TrimRight(specification.Doc.Text(), "\n"),
+		Name:           specification.Name.Name,
+		Fields:         parseStructFields(structType, fieldDocs),
+		Doc:            doc,
+		Directives:     directives,
+		TypeParameters: typeParameters,
 	}
 }
 
-func parseStructFields(structType *types.Struct) []ExportedField {
+func parseStructFields(structType *types.Struct, fieldDocs map[token.Pos]*ast.CommentGroup) []ExportedField {
 	exportedFields := make([]ExportedField, 0, structType.NumFields())
-	for field := range structType.Fields() {
+	for index := range structType.NumFields() {
+		field := structType.Field(index)
+		doc, directives := parseDirectives(fieldDocs[field.Pos()])
+		if parseDeclarationDirectives(directives).Ignore {
+			continue
+		}
 		exportedFields = append(exportedFields, ExportedField{
-			Name: field.Name(),
-			Type: field.Type().String(),
-			// TODO: Find a way to get the doc
+			Name:       field.Name(),
+			Type:       field.Type().String(),
+			Embedded:   field.Embedded(),
+			Tags:       parseStructTag(structType.Tag(index)),
+			Doc:        doc,
+			Directives: directives,
 		})
 	}
 	return exportedFields
 }
 
-func parseExportedInterface(interfaceType *types.Interface, specification *ast.TypeSpec) ExportedInterface {
+// parseStructTag splits a struct tag into its key:"value" pairs, following
+// the conventional format understood by reflect.StructTag. Malformed tags
+// stop the parsing, as reflect does.
+func parseStructTag(tag string) map[string]string {
+	var tags map[string]string
+	for tag != "" {
+		tag = strings.TrimLeft(tag, " ")
+		key, rest, ok := strings.Cut(tag, ":")
+		if !ok || key == "" || strings.ContainsAny(key, " \"\x7f") || !strings.HasPrefix(rest, "\"") {
+			break
+		}
+		quoted, err := strconv.QuotedPrefix(rest)
+		if err != nil {
+			break
+		}
+		value, err := strconv.Unquote(quoted)
+		if err != nil {
+			break
+		}
+		if tags == nil {
+			tags = map[string]string{}
+		}
+		tags[key] = value
+		tag = rest[len(quoted):]
+	}
+	return tags
+}
+
+func parseExportedInterface(interfaceType *types.Interface, specification *ast.TypeSpec, doc string, fieldDocs map[token.Pos]*ast.CommentGroup) ExportedInterface {
 	return ExportedInterface{
 		Name:    specification.Name.Name,
-		Methods: parseInterfaceMethods(interfaceType),
-		Doc:     strings.TrimRight(specification.Doc.Text(), "\n"),
+		Methods: parseInterfaceMethods(interfaceType, fieldDocs),
+		Doc:     doc,
 	}
 }
 
-func parseInterfaceMethods(interfaceType *types.Interface) []ExportedRoutine {
-	exportedMethods := make([]ExportedRoutine, 0, interfaceType.NumExplicitMethods())
-	for method := range interfaceType.ExplicitMethods() {
-		exportedMethods = append(exportedMethods, parseInterfaceMethod(method))
+func parseInterfaceMethods(interfaceType *types.Interface, fieldDocs map[token.Pos]*ast.CommentGroup) []ExportedRoutine {
+	exportedMethods := make([]ExportedRoutine, 0, interfaceType.NumMethods())
+	for method := range interfaceType.Methods() {
+		exportedMethods = append(exportedMethods, parseInterfaceMethod(method, fieldDocs))
 	}
 	return exportedMethods
 }
 
-func parseInterfaceMethod(method *types.Func) ExportedRoutine {
+func parseInterfaceMethod(method *types.Func, fieldDocs map[token.Pos]*ast.CommentGroup) ExportedRoutine {
+	doc, directives := parseDirectives(fieldDocs[method.Pos()])
 	return ExportedRoutine{
 		Name:        method.Name(),
-		Arguments:   parseArguments(method.Signature().Params()),
+		Arguments:   parseArguments(method.Signature()),
 		ReturnTypes: parseReturnTypes(method.Signature().Results()),
+		Doc:         doc,
+		Directives:  directives,
 	}
 }
 
 func findStructByName(structs []ExportedStruct, name string) *ExportedStruct {
-	for _, exportedStruct := range structs {
-		if exportedStruct.Name == name {
-			return &exportedStruct
+	for index := range structs {
+		if structs[index].Name == name {
+			return &structs[index]
 		}
 	}
 	return nil
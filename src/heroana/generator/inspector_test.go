@@ -2,6 +2,8 @@ package generator_test
 
 import (
 	"reflect"
+	"slices"
+	"strings"
 	"testing"
 
 	"github.com/EdmilsonRodrigues/melo-project/src/melo/generator"
@@ -15,8 +17,32 @@ func TestInspectPackage(t *testing.T) {
 			{
 				Name:  "MyConst",
 				Type:  "string",
-				Value: "hello", 
-				// Doc:   "Go doc for my constant",  // TODO Uncomment after finding a way to get the doc
+				Value: "hello",
+				Doc:   "Go doc for my constant",
+			},
+			{
+				Name:  "MyRuneConst",
+				Type:  "rune",
+				Value: 'a',
+				Doc:   "Go doc for my rune constant",
+			},
+			{
+				Name:  "MyFirstGroupedConst",
+				Type:  "int",
+				Value: int64(1),
+				Doc:   "Go doc for my first grouped constant",
+			},
+			{
+				Name:  "MySecondGroupedConst",
+				Type:  "int",
+				Value: int64(2),
+				Doc:   "Go doc for my second grouped constant",
+			},
+			{
+				Name:  "MyThirdGroupedConst",
+				Type:  "int",
+				Value: int64(3),
+				Doc:   "Go doc for my grouped constants",
 			},
 		},
 		ExportedVariables: []generator.ExportedVariable{
@@ -24,14 +50,27 @@ func TestInspectPackage(t *testing.T) {
 				Name:  "MyVar",
 				Type:  "string",
 				Value: "world",
-				// Doc:   "Go doc for my variable",  // TODO Uncomment after finding a way to get the doc
+				Doc:   "Go doc for my variable",
+			},
+			{
+				Name:       "MyReadOnlyVar",
+				Type:       "int",
+				Doc:        "Go doc for my read-only variable",
+				Directives: []string{"readonly"},
 			},
 		},
 		ExportedTypes: []generator.ExportedType{
 			{
 				Name: "MyType",
 				Type: "string",
-				// Doc:  "Go doc for my type",  // TODO Uncomment after finding a way to get the doc
+				Doc:  "Go doc for my type",
+			},
+		},
+		ExportedAliases: []generator.ExportedAlias{
+			{
+				Name: "MyAlias",
+				Type: fixturePath + ".MyStruct",
+				Doc:  "Go doc for my alias",
 			},
 		},
 		ExportedStructs: []generator.ExportedStruct{
@@ -41,7 +80,7 @@ func TestInspectPackage(t *testing.T) {
 					{
 						Name: "Name",
 						Type: "string",
-						// Doc:  "Go doc for my field",  // TODO Uncomment after finding a way to get the doc
+						Doc:  "Go doc for my field",
 					},
 				},
 				Methods: []generator.ExportedRoutine{
@@ -63,6 +102,35 @@ func TestInspectPackage(t *testing.T) {
 				},
 				Doc: "Go doc for my struct",
 			},
+			{
+				Name: "MyEmbeddingStruct",
+				Fields: []generator.ExportedField{
+					{
+						Name:     "MyStruct",
+						Type:     fixturePath + ".MyStruct",
+						Embedded: true,
+					},
+					{
+						Name: "Extra",
+						Type: "int",
+						Tags: map[string]string{"json": "extra,omitempty", "melo": "readonly"},
+						Doc:  "Go doc for my extra field",
+					},
+				},
+				Doc: "Go doc for my embedding struct",
+			},
+			{
+				Name: "MyIgnoringStruct",
+				Fields: []generator.ExportedField{
+					{
+						Name:       "Kept",
+						Type:       "string",
+						Doc:        "Go doc for my renamed field",
+						Directives: []string{"name renamed"},
+					},
+				},
+				Doc: "Go doc for my struct with an ignored field",
+			},
 		},
 		ExportedInterfaces: []generator.ExportedInterface{
 			{
@@ -114,11 +182,38 @@ func TestInspectPackage(t *testing.T) {
 				ReturnTypes: []string{"int", "error"},
 				Doc:         "Go doc for my second function",
 			},
+			{
+				Name: "SumNumbers",
+				Arguments: []generator.ExportedArgument{
+					{
+						Name:     "numbers",
+						Type:     "[]int",
+						Variadic: true,
+					},
+				},
+				ReturnTypes: []string{"int"},
+				Doc:         "Go doc for my variadic function",
+			},
+			{
+				Name:        "MyHandler",
+				Arguments:   []generator.ExportedArgument{},
+				ReturnTypes: []string{"net/http.Handler"},
+				Doc:         "Go doc for my handler",
+				Directives:  []string{"asgi"},
+			},
+			{
+				Name:           "Identity",
+				Arguments:      []generator.ExportedArgument{{Name: "value", Type: "T"}},
+				ReturnTypes:    []string{"T"},
+				Doc:            "Go doc for my generic function",
+				Directives:     []string{"instantiate Identity[int]"},
+				TypeParameters: []string{"T"},
+			},
 		},
 	}
 
 	t.Run("should inspect fixture package", func(t *testing.T) {
-		inspectedContents, err := generator.InspectPackage(fixturePath)
+		inspectedContents, err := generator.InspectPackage("", fixturePath)
 		if err != nil {
 			t.Errorf("InspectPackage should not return error, got %v", err)
 		}
@@ -128,3 +223,101 @@ func TestInspectPackage(t *testing.T) {
 		}
 	})
 }
+
+func TestInspectExamples(t *testing.T) {
+	fixturePath := "github.com/EdmilsonRodrigues/melo-project/src/melo/generator/fixtures"
+
+	examples, err := generator.InspectExamples("", fixturePath)
+	if err != nil {
+		t.Fatalf("InspectExamples should not return error, got %v", err)
+	}
+
+	t.Run("should read the calls of the package functions", func(t *testing.T) {
+		expected := []generator.ExportedExample{
+			{
+				Name: "SumTwoNumbers",
+				Calls: []generator.ExampleCall{
+					{Function: "SumTwoNumbers", Arguments: []string{"1", "-2"}, Printed: true},
+					{Function: "SumTwoNumbers", Arguments: []string{"0x10", "'a'"}, Printed: true},
+				},
+				Output:    "-1\n113\n",
+				HasOutput: true,
+			},
+			{
+				Name: "SumNumbers",
+				Calls: []generator.ExampleCall{
+					{Function: "SumNumbers", Arguments: []string{"1", "2"}},
+					{Function: "SumNumbers", Arguments: []string{"1", "2", "3"}, Printed: true},
+				},
+				Output:    "6\n",
+				HasOutput: true,
+			},
+		}
+		for _, example := range expected {
+			if !slices.ContainsFunc(examples, func(inspected generator.ExportedExample) bool { return reflect.DeepEqual(inspected, example) }) {
+				t.Errorf("InspectExamples should return %+v, got %+v", example, examples)
+			}
+		}
+	})
+
+	t.Run("should explain why an example cannot be read", func(t *testing.T) {
+		index := slices.IndexFunc(examples, func(example generator.ExportedExample) bool { return example.Name == "MyStruct" })
+		if index < 0 {
+			t.Fatalf("InspectExamples should return ExampleMyStruct, got %+v", examples)
+		}
+		if problem := examples[index].Problem; !strings.Contains(problem, "only calls are translated") {
+			t.Errorf("InspectExamples should explain the problem, got %q", problem)
+		}
+	})
+}
+
+func TestInspectDeclarations(t *testing.T) {
+	fixturePath := "github.com/EdmilsonRodrigues/melo-project/src/melo/generator/fixtures"
+
+	declarations, err := generator.InspectDeclarations("", fixturePath)
+	if err != nil {
+		t.Fatalf("InspectDeclarations should not return error, got %v", err)
+	}
+
+	t.Run("should read the Go source and position of declarations", func(t *testing.T) {
+		for name, expected := range map[string]generator.GoDeclaration{
+			"MyConst":                    {Source: "const MyConst = \"hello\"", Position: "fixture.go:8"},
+			"SumTwoNumbers":              {Source: "func SumTwoNumbers(a, b int) int", Position: "fixture.go:54"},
+			"MyStruct.CanSumTwoNumbers2": {Source: "func (s MyStruct) CanSumTwoNumbers2(a, b int) (sum int, err error)", Position: "fixture.go:86"},
+			"MyStruct.Name":              {Source: "Name string", Position: "fixture.go:35"},
+			"MyEmbeddingStruct.MyStruct": {Source: "MyStruct", Position: "fixture.go:43"},
+			"MyInterface.SayHello":       {Source: "SayHello(name string) string", Position: "fixture.go:50"},
+		} {
+			if declaration := declarations[name]; !reflect.DeepEqual(declaration, expected) {
+				t.Errorf("InspectDeclarations should return %+v for %s, got %+v", expected, name, declaration)
+			}
+		}
+	})
+
+	t.Run("should leave out the doc comment of the declaration", func(t *testing.T) {
+		if source := declarations["MyStruct"].Source; source != "type MyStruct struct {\n\t// Go doc for my field\n\tName string\n}" {
+			t.Errorf("InspectDeclarations should return the struct without its doc, got %q", source)
+		}
+	})
+}
+
+func TestInspectPackageDirectives(t *testing.T) {
+	_, err := generator.InspectPackage("", "github.com/EdmilsonRodrigues/melo-project/src/melo/generator/fixtures/directives")
+	if err == nil {
+		t.Fatal("InspectPackage should return error for invalid directives")
+	}
+
+	for _, expected := range []string{
+		"directives.go:6:2: \"class\" is not a Python identifier",
+		"directives.go:10:1: unknown directive \"unknown\"",
+		"directives.go:13:1: the \"readonly\" directive does not apply to functions",
+		"directives.go:16:1: the \"name\" directive takes a single argument, got \"first second\"",
+		"directives.go:19:1: the \"asgi\" directive does not apply to types",
+		"directives.go:21:2: the \"ignore\" directive does not apply to interface methods",
+		"directives.go:25:1: the \"readonly\" directive takes no argument, got \"now\"",
+	} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("InspectPackage should report %q, got %v", expected, err)
+		}
+	}
+}
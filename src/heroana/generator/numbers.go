@@ -0,0 +1,111 @@
+package generator
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// integerRange is the range of values of an integer type, checked before the
+// value reaches ctypes since ctypes silently wraps out of range integers.
+func (bridge bridgeType) integerRange() (low, high string) {
+	switch bridge.basic {
+	case "int8":
+		return strconv.Itoa(math.MinInt8), strconv.Itoa(math.MaxInt8)
+	case "int16":
+		return strconv.Itoa(math.MinInt16), strconv.Itoa(math.MaxInt16)
+	case "int32":
+		return strconv.Itoa(math.MinInt32), strconv.Itoa(math.MaxInt32)
+	case "uint8":
+		return "0", strconv.Itoa(math.MaxUint8)
+	case "uint16":
+		return "0", strconv.Itoa(math.MaxUint16)
+	case "uint32":
+		return "0", strconv.Itoa(math.MaxUint32)
+	}
+	if bridge.kind == uintKind {
+		return "0", strconv.FormatUint(math.MaxUint64, 10)
+	}
+	return strconv.Itoa(math.MinInt64), strconv.Itoa(math.MaxInt64)
+}
+
+// textual reports whether values of the type cross the bridge as C strings,
+// which the receiving side frees. Complex and arbitrary precision numbers are
+// formatted as text so that no precision is lost.
+func (bridge bridgeType) textual() bool {
+	switch bridge.kind {
+	case stringKind, complexKind, bigIntKind, bigFloatKind, bigRatKind:
+		return true
+	}
+	return false
+}
+
+// complexBits is the size of the complex type, used to format it without
+// spurious digits.
+func (bridge bridgeType) complexBits() int {
+	if bridge.basic == "complex64" {
+		return 64
+	}
+	return 128
+}
+
+// pythonParser is the Python callable building a number from its text.
+func (bridge bridgeType) pythonParser() string {
+	switch bridge.kind {
+	case complexKind:
+		return "_parse_complex"
+	case bigIntKind:
+		return "int"
+	case bigFloatKind:
+		return "decimal.Decimal"
+	case bigRatKind:
+		return "fractions.Fraction"
+	}
+	return "str"
+}
+
+// pythonFormatter is the Python runtime function formatting a number as the
+// bytes parsed by the bridge.
+func (bridge bridgeType) pythonFormatter() string {
+	switch bridge.kind {
+	case complexKind:
+		return "_complex_text"
+	case bigIntKind:
+		return "_integer_text"
+	case bigFloatKind:
+		return "_decimal_text"
+	case bigRatKind:
+		return "_fraction_text"
+	}
+	return ""
+}
+
+// numberToGo parses the text of a number crossing the bridge.
+func (bridge bridgeType) numberToGo(expression string) string {
+	switch bridge.kind {
+	case complexKind:
+		return fmt.Sprintf("%s(parseComplex(%s))", bridge.goType, expression)
+	case bigIntKind:
+		return fmt.Sprintf("parseBigInt(%s)", expression)
+	case bigFloatKind:
+		return fmt.Sprintf("parseBigFloat(%s)", expression)
+	case bigRatKind:
+		return fmt.Sprintf("parseBigRat(%s)", expression)
+	}
+	return expression
+}
+
+// numberToC formats a number as the text crossing the bridge.
+func (bridge bridgeType) numberToC(variable string) string {
+	switch bridge.kind {
+	case complexKind:
+		return fmt.Sprintf("formatComplex(complex128(%s), %d)", variable, bridge.complexBits())
+	case bigIntKind:
+		return fmt.Sprintf("formatBigInt(%s)", variable)
+	case bigFloatKind:
+		return fmt.Sprintf("formatBigFloat(%s)", variable)
+	case bigRatKind:
+		return fmt.Sprintf("formatBigRat(%s)", variable)
+	}
+	return variable
+}
@@ -0,0 +1,1379 @@
+package generator
+
+import (
+	"fmt"
+	"log"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/EdmilsonRodrigues/melo-project/src/melo/files"
+)
+
+// PythonRuntime is the Python module shared by every generated module. It
+// loads the shared library built from the bridges and keeps the Python
+// objects referenced from Go alive.
+const PythonRuntime = `"""Runtime support for Melo generated modules. DO NOT EDIT."""
+
+import asyncio
+import ctypes
+import decimal
+import fractions
+import http
+import inspect
+import io
+import itertools
+import operator
+import os
+import typing
+import urllib.parse
+
+lib = ctypes.CDLL(
+    os.environ.get(
+        "MELO_LIBRARY",
+        os.path.join(os.path.dirname(os.path.abspath(__file__)), "libmelo.so"),
+    )
+)
+lib.melo_free.argtypes = [ctypes.c_void_p]
+lib.melo_free.restype = None
+lib.melo_release.argtypes = [ctypes.c_size_t]
+lib.melo_release.restype = None
+lib.melo_string.argtypes = [ctypes.c_char_p]
+lib.melo_string.restype = ctypes.c_void_p
+
+
+class GoError(Exception):
+    """Raised when a Go call returns a non-nil error."""
+
+
+class GoObject:
+    """Base class of the Python objects backed by a Go value."""
+
+    _handle = 0
+
+    @classmethod
+    def _from_handle(cls, handle):
+        instance = cls.__new__(cls)
+        instance._handle = handle
+        return instance
+
+    _fields = ()
+
+    def to_dict(self):
+        """Returns the fields of the Go value, nested Go values included, keyed
+        by their Python names."""
+        return {name: _serialize(getattr(self, name)) for name in self._fields}
+
+    def __del__(self):
+        if self._handle:
+            lib.melo_release(self._handle)
+            self._handle = 0
+
+
+def _serialize(value):
+    if isinstance(value, GoObject):
+        return value.to_dict()
+    if isinstance(value, (list, tuple)):
+        return [_serialize(item) for item in value]
+    if isinstance(value, dict):
+        return {key: _serialize(item) for key, item in value.items()}
+    return value
+
+
+class ClosedError(GoError, ValueError):
+    """Raised when using a Go value after closing it."""
+
+
+class Closable(GoObject):
+    """Base class of the Go values with a Close method, usable as context
+    managers. Closing releases the Go value, which cannot be used afterwards.
+    """
+
+    _value = 0
+
+    @property
+    def _handle(self):
+        if not self._value:
+            raise ClosedError(f"{type(self).__name__} is closed")
+        return self._value
+
+    @_handle.setter
+    def _handle(self, handle):
+        self._value = handle
+
+    def _close(self):
+        raise NotImplementedError
+
+    def Close(self) -> None:
+        """Close closes the Go value and releases it. Closing twice does nothing."""
+        if not self._value:
+            return
+        try:
+            self._close()
+        finally:
+            lib.melo_release(self._value)
+            self._value = 0
+
+    def __enter__(self):
+        return self
+
+    def __exit__(self, *exc_info):
+        self.Close()
+
+    def __del__(self):
+        if self._value:
+            lib.melo_release(self._value)
+            self._value = 0
+
+
+class AsyncClosable(Closable):
+    """Closable of the Go values with coroutine methods, also usable as
+    asynchronous context managers closing them in a worker thread.
+    """
+
+    async def __aenter__(self):
+        return self
+
+    async def __aexit__(self, *exc_info):
+        await asyncio.to_thread(self.Close)
+
+
+class Handler(GoObject):
+    """ASGI application forwarding HTTP requests to a Go http.Handler in
+    process. The wsgi method serves the same handler as a WSGI application.
+    Request and response bodies are buffered.
+    """
+
+    def _serve(self, *arguments):
+        raise NotImplementedError
+
+    def serve(self, method, target, headers, body=b"", remote=""):
+        """Serve one request, returning its status, headers and body."""
+        encoded = "".join(f"{name}: {value}\n" for name, value in headers)
+        status = ctypes.c_longlong()
+        response_headers = ctypes.c_void_p()
+        response = ctypes.c_void_p()
+        length = ctypes.c_size_t()
+        self._serve(
+            method.encode(),
+            target.encode("latin-1"),
+            encoded.encode("latin-1"),
+            body,
+            len(body),
+            remote.encode(),
+            ctypes.byref(status),
+            ctypes.byref(response_headers),
+            ctypes.byref(response),
+            ctypes.byref(length),
+        )
+        content = ctypes.string_at(response.value, length.value)
+        lib.melo_free(response.value)
+        lines = string(response_headers.value).split("\n")
+        return status.value, [tuple(line.split(": ", 1)) for line in lines if line], content
+
+    async def __call__(self, scope, receive, send):
+        if scope["type"] == "lifespan":
+            while True:
+                message = await receive()
+                if message["type"] == "lifespan.startup":
+                    await send({"type": "lifespan.startup.complete"})
+                elif message["type"] == "lifespan.shutdown":
+                    await send({"type": "lifespan.shutdown.complete"})
+                    return
+        if scope["type"] != "http":
+            raise ValueError(f"unsupported ASGI scope type {scope['type']!r}")
+
+        body = b""
+        while True:
+            message = await receive()
+            body += message.get("body", b"")
+            if not message.get("more_body", False):
+                break
+
+        target = scope.get("raw_path") or urllib.parse.quote(scope["root_path"] + scope["path"]).encode()
+        if scope.get("query_string"):
+            target += b"?" + scope["query_string"]
+        headers = [(name.decode("latin-1"), value.decode("latin-1")) for name, value in scope["headers"]]
+        client = scope.get("client")
+        remote = f"{client[0]}:{client[1]}" if client else ""
+        status, response_headers, content = await asyncio.to_thread(
+            self.serve, scope["method"], target.decode("latin-1"), headers, body, remote
+        )
+        await send(
+            {
+                "type": "http.response.start",
+                "status": status,
+                "headers": [(name.lower().encode("latin-1"), value.encode("latin-1")) for name, value in response_headers],
+            }
+        )
+        await send({"type": "http.response.body", "body": content})
+
+    def wsgi(self, environ, start_response):
+        """Serve a request as a WSGI application."""
+        length = int(environ.get("CONTENT_LENGTH") or 0)
+        body = environ["wsgi.input"].read(length) if length else b""
+        path = environ.get("SCRIPT_NAME", "") + environ.get("PATH_INFO", "")
+        target = urllib.parse.quote(path.encode("latin-1"))
+        if environ.get("QUERY_STRING"):
+            target += "?" + environ["QUERY_STRING"]
+        headers = [
+            (name[5:].replace("_", "-").title(), value) for name, value in environ.items() if name.startswith("HTTP_")
+        ]
+        for name in ("CONTENT_TYPE", "CONTENT_LENGTH"):
+            if environ.get(name):
+                headers.append((name.replace("_", "-").title(), environ[name]))
+        remote = f"{environ.get('REMOTE_ADDR', '')}:{environ.get('REMOTE_PORT', '0')}"
+        status, response_headers, content = self.serve(environ["REQUEST_METHOD"], target, headers, body, remote)
+        try:
+            phrase = http.HTTPStatus(status).phrase
+        except ValueError:
+            # Codes unknown to Python, such as 299, have no reason phrase.
+            phrase = ""
+        start_response(f"{status} {phrase}", response_headers)
+        return [content]
+
+
+T = typing.TypeVar("T")
+
+
+class ReceiveChannel(GoObject, typing.Generic[T]):
+    """Iterator and async iterator over the values received from a Go channel.
+
+    Iteration stops when Go closes the channel. Closing it from Python, which
+    happens when the iterator is garbage collected, cancels the context of the
+    Go producer. The channels of producers that take no context.Context are
+    drained in the background instead, until the producer closes them.
+    """
+
+    _closed = False
+
+    def _receive(self):
+        raise NotImplementedError
+
+    def _close(self):
+        raise NotImplementedError
+
+    def __iter__(self):
+        return self
+
+    def __next__(self) -> T:
+        if self._closed:
+            raise StopIteration
+        value, ok = self._receive()
+        if not ok:
+            self.close()
+            raise StopIteration
+        return value
+
+    def __aiter__(self):
+        return self
+
+    async def __anext__(self) -> T:
+        if self._closed:
+            raise StopAsyncIteration
+        value, ok = await asyncio.to_thread(self._receive)
+        if not ok:
+            self.close()
+            raise StopAsyncIteration
+        return value
+
+    def close(self):
+        if not self._closed and self._handle:
+            self._closed = True
+            self._close()
+
+    async def aclose(self):
+        self.close()
+
+    def __enter__(self):
+        return self
+
+    def __exit__(self, *exc_info):
+        self.close()
+
+    def __del__(self):
+        self.close()
+        super().__del__()
+
+
+class Sequence(GoObject, typing.Generic[T]):
+    """Pull-based iteration over an iter.Seq or iter.Seq2 returned by Go.
+
+    Iterating returns a generator pulling one value per step, so Python sets
+    the pace. Closing the generator, or breaking out of the loop, stops the
+    Go sequence.
+    """
+
+    _stopped = False
+
+    def _pull(self):
+        raise NotImplementedError
+
+    def _stop(self):
+        raise NotImplementedError
+
+    def __iter__(self) -> typing.Generator[T, None, None]:
+        try:
+            while not self._stopped:
+                *values, ok = self._pull()
+                if not ok:
+                    return
+                yield values[0] if len(values) == 1 else tuple(values)
+        finally:
+            self.stop()
+
+    def stop(self):
+        if not self._stopped and self._handle:
+            self._stopped = True
+            self._stop()
+
+    def __del__(self):
+        self.stop()
+        super().__del__()
+
+
+def check(failure):
+    if failure:
+        raise GoError(string(failure))
+
+
+def string(pointer):
+    value = ctypes.string_at(pointer).decode()
+    lib.melo_free(pointer)
+    return value
+
+
+def deprecated(message):
+    """Marks a declaration as deprecated like warnings.deprecated, without
+    wrapping it: generated code warns the caller itself."""
+
+    def decorate(declaration):
+        declaration.__deprecated__ = message
+        return declaration
+
+    return decorate
+
+
+def bounded(value, low, high):
+    """Returns the integer if the Go integer type can hold it, since ctypes
+    would silently wrap it."""
+    value = operator.index(value)
+    if not low <= value <= high:
+        raise OverflowError(f"{value} is out of range [{low}, {high}]")
+    return value
+
+
+def number(value, parse):
+    """Parses a number sent as text by Go, either a result to free or the
+    bytes of a callback argument. Nil pointers become None."""
+    if not value:
+        return None
+    if isinstance(value, bytes):
+        return parse(value.decode())
+    return parse(string(value))
+
+
+def parse_complex(text):
+    return complex(text.replace("i", "j"))
+
+
+def complex_text(value):
+    value = complex(value)
+    imag = repr(value.imag)
+    if not imag.startswith("-"):
+        imag = "+" + imag
+    return f"({value.real!r}{imag}i)".encode()
+
+
+def integer_text(value):
+    if value is None:
+        return None
+    return str(operator.index(value)).encode()
+
+
+def decimal_text(value):
+    if value is None:
+        return None
+    value = decimal.Decimal(value)
+    if value.is_nan():
+        raise ValueError("NaN cannot be converted to a Go big.Float")
+    if value.is_infinite():
+        return b"-Inf" if value < 0 else b"+Inf"
+    return str(value).encode()
+
+
+def fraction_text(value):
+    if value is None:
+        return None
+    value = fractions.Fraction(value)
+    return f"{value.numerator}/{value.denominator}".encode()
+
+
+_refs = {}
+_next_ref = itertools.count(1)
+
+
+def ref(value):
+    key = next(_next_ref)
+    _refs[key] = value
+    return key
+
+
+def deref(key):
+    return _refs[key]
+
+
+@ctypes.CFUNCTYPE(None, ctypes.c_size_t)
+def _release_ref(key):
+    _refs.pop(key, None)
+
+
+lib.melo_set_release_ref.argtypes = [ctypes.CFUNCTYPE(None, ctypes.c_size_t)]
+lib.melo_set_release_ref.restype = None
+lib.melo_set_release_ref(_release_ref)
+
+
+def dispatch(name, instances, args, kwargs):
+    """Call the first instantiation of a generic Go function accepting the
+    arguments, given the classes accepted by each of its generic parameters.
+    """
+    for function, checks in instances:
+        signature = inspect.signature(function)
+        try:
+            arguments = signature.bind(*args, **kwargs).arguments
+        except TypeError:
+            continue
+        if all(_accepts(signature.parameters[key], arguments[key], classes) for key, classes in checks.items() if key in arguments):
+            return function(*args, **kwargs)
+    raise TypeError(f"no instantiation of {name} accepts the given arguments")
+
+
+def _accepts(parameter, value, classes):
+    values = value if parameter.kind is inspect.Parameter.VAR_POSITIONAL else (value,)
+    return all(isinstance(value, classes) and (bool in classes or not isinstance(value, bool)) for value in values)
+
+
+class Readable(typing.Protocol):
+    """Binary file-like object passed to Go as an io.Reader."""
+
+    def read(self, size: int = -1, /) -> bytes: ...
+
+
+class Writable(typing.Protocol):
+    """Binary file-like object passed to Go as an io.Writer."""
+
+    def write(self, data: bytes, /) -> int | None: ...
+
+
+class GoReader(io.RawIOBase):
+    """Binary stream reading from an io.Reader returned by Go, closing it
+    when the reader is also an io.Closer.
+    """
+
+    def __init__(self, handle):
+        super().__init__()
+        self._handle = handle
+
+    @classmethod
+    def _from_handle(cls, handle):
+        return cls(handle)
+
+    def readable(self):
+        return True
+
+    def readinto(self, buffer):
+        if self.closed:
+            raise ValueError("I/O operation on closed stream")
+        view = memoryview(buffer).cast("B")
+        if not len(view):
+            return 0
+        count = ctypes.c_size_t()
+        pointer = (ctypes.c_char * len(view)).from_buffer(view)
+        check(lib.melo_reader_read(self._handle, pointer, len(view), ctypes.byref(count)))
+        return count.value
+
+    def close(self):
+        if self._handle:
+            handle, self._handle = self._handle, 0
+            try:
+                check(lib.melo_reader_close(handle))
+            finally:
+                lib.melo_release(handle)
+        super().close()
+
+
+lib.melo_reader_read.argtypes = [ctypes.c_size_t, ctypes.c_void_p, ctypes.c_size_t, ctypes.POINTER(ctypes.c_size_t)]
+lib.melo_reader_read.restype = ctypes.c_void_p
+lib.melo_reader_close.argtypes = [ctypes.c_size_t]
+lib.melo_reader_close.restype = ctypes.c_void_p
+
+_io_type = ctypes.CFUNCTYPE(
+    None,
+    ctypes.c_size_t,
+    ctypes.c_void_p,
+    ctypes.c_size_t,
+    ctypes.POINTER(ctypes.c_size_t),
+    ctypes.POINTER(ctypes.c_void_p),
+)
+
+
+@_io_type
+def _read(key, buffer, size, count, failure):
+    try:
+        data = deref(key).read(size)
+        ctypes.memmove(buffer, data, len(data))
+        count[0] = len(data)
+    except Exception as error:
+        failure[0] = lib.melo_string(str(error).encode())
+
+
+@_io_type
+def _write(key, buffer, size, count, failure):
+    try:
+        written = deref(key).write(ctypes.string_at(buffer, size))
+        count[0] = size if written is None else written
+    except Exception as error:
+        failure[0] = lib.melo_string(str(error).encode())
+
+
+lib.melo_set_io.argtypes = [_io_type, _io_type]
+lib.melo_set_io.restype = None
+lib.melo_set_io(_read, _write)
+`
+
+const pythonIndent = "    "
+
+// GeneratePythonModule renders the Python module exposing the exported
+// objects of a package through the bridge rendered by GenerateBridge.
+func GeneratePythonModule(exportedPackage files.ExportedPackage, objects ExportedObjects, dependencies ...Dependency) (string, error) {
+	context := newGeneratorContext(exportedPackage, objects)
+	context.addDependencies(dependencies)
+	context.reportExclusions()
+	declarations := &strings.Builder{}
+	definitions := &strings.Builder{}
+
+	context.writeConstants(definitions)
+
+	for _, exportedInterface := range context.objects.ExportedInterfaces {
+		if !context.proxyable(exportedInterface) {
+			continue
+		}
+		context.writeProtocol(declarations, definitions, exportedInterface)
+	}
+
+	for _, exportedStruct := range context.objects.ExportedStructs {
+		context.writeClass(declarations, definitions, exportedStruct)
+	}
+
+	doctests := map[string]string{}
+	if exportedPackage.Doctests {
+		doctests = context.doctests()
+	}
+	for _, function := range context.objects.ExportedFunctions {
+		if _, _, constructor := context.constructorOf(function); constructor {
+			continue
+		}
+		call, ok := context.resolveRoutine(function, context.symbol(function.Name))
+		if !ok {
+			log.Printf("Skipping function %s: unsupported types", function.Name)
+			continue
+		}
+		call.Examples = doctests[function.Name]
+		writeCallDeclaration(declarations, call, false)
+		writePythonCall(definitions, "", call, false)
+	}
+
+	for _, generic := range context.generics {
+		context.writeGenericDispatch(definitions, generic)
+	}
+
+	context.writeAliases(definitions)
+
+	context.writeModuleProperties(declarations, definitions, context.exportedVariables())
+
+	for _, helper := range context.helpers {
+		switch helper.kind {
+		case receiveChannelKind:
+			writeReceiverClass(declarations, definitions, helper)
+		case feedChannelKind:
+			writeFeederCallback(definitions, helper)
+		case sequenceKind:
+			writeSequenceClass(declarations, definitions, helper)
+		case handlerKind:
+			writeHandlerClass(declarations, definitions, helper)
+		case functionKind:
+			writeFunctionClass(declarations, definitions, helper)
+		case anonymousStructKind:
+			writeTypedDict(definitions, helper)
+		}
+	}
+
+	// Type checkers see the standard decorator and report uses of deprecated
+	// declarations, while the generated code warns at runtime.
+	shim := &strings.Builder{}
+	if strings.Contains(definitions.String(), "@_deprecated(") {
+		shim.WriteString("if typing.TYPE_CHECKING:\n")
+		shim.WriteString("    if sys.version_info >= (3, 13):\n        from warnings import deprecated as _deprecated\n")
+		shim.WriteString("    else:\n        from typing_extensions import deprecated as _deprecated\n")
+		shim.WriteString("else:\n    from _melo import deprecated as _deprecated\n\n")
+	}
+
+	module := &strings.Builder{}
+	fmt.Fprintf(module, "\"\"\"Generated by Melo from %s. DO NOT EDIT.\"\"\"\n\n", exportedPackage.GoPath)
+	module.WriteString("from __future__ import annotations\n\n")
+	writePythonImports(module, shim.String()+declarations.String()+definitions.String())
+	module.WriteString("\n")
+	module.WriteString("from _melo import AsyncClosable, Closable, ClosedError, GoError, GoObject, GoReader, Handler, Readable, ReceiveChannel, Sequence, Writable\n")
+	module.WriteString("from _melo import bounded as _bounded\n")
+	module.WriteString("from _melo import check as _check\n")
+	module.WriteString("from _melo import complex_text as _complex_text\n")
+	module.WriteString("from _melo import decimal_text as _decimal_text\n")
+	module.WriteString("from _melo import deref as _deref\n")
+	module.WriteString("from _melo import dispatch as _dispatch\n")
+	module.WriteString("from _melo import fraction_text as _fraction_text\n")
+	module.WriteString("from _melo import integer_text as _integer_text\n")
+	module.WriteString("from _melo import lib as _lib\n")
+	module.WriteString("from _melo import number as _number\n")
+	module.WriteString("from _melo import parse_complex as _parse_complex\n")
+	module.WriteString("from _melo import ref as _ref\n")
+	module.WriteString("from _melo import string as _string\n\n")
+	module.WriteString(shim.String())
+	if len(context.imports) > 0 {
+		for _, goPath := range slices.Sorted(maps.Keys(context.imports)) {
+			dependency := context.dependencies[goPath].exportedPackage
+			fmt.Fprintf(module, "import %s as %s\n", dependency.PythonPath, pythonModuleAlias(dependency))
+		}
+		module.WriteString("\n")
+	}
+	module.WriteString(declarations.String())
+	module.WriteString("\n")
+	module.WriteString(definitions.String())
+
+	return strings.TrimRight(module.String(), "\n") + "\n", nil
+}
+
+var pythonStandardImports = []string{"asyncio", "ctypes", "datetime", "decimal", "fractions", "sys", "types", "typing", "warnings"}
+
+// writePythonImports imports the standard modules the generated code uses,
+// like writeBridgeImports does for the bridge.
+func writePythonImports(module *strings.Builder, code string) {
+	for _, standardImport := range pythonStandardImports {
+		if packageUsage(standardImport).MatchString(code) {
+			fmt.Fprintf(module, "import %s\n", standardImport)
+		}
+	}
+}
+
+// writeCallDeclaration declares the ctypes signature of an exported function.
+func writeCallDeclaration(declarations *strings.Builder, call bridgeCall, receiver bool) {
+	argumentTypes := []string{}
+	if receiver {
+		argumentTypes = append(argumentTypes, "ctypes.c_size_t")
+	}
+	for _, argument := range call.Arguments {
+		switch {
+		case argument.Type.kind == variadicKind:
+			argumentTypes = append(argumentTypes, fmt.Sprintf("ctypes.POINTER(%s)", argument.Type.element.ctypesType()), "ctypes.c_size_t")
+		case argument.Type.kind == optionsKind:
+			for _, option := range argument.Type.options {
+				argumentTypes = append(argumentTypes, "ctypes.c_bool")
+				if option.Value != nil {
+					argumentTypes = append(argumentTypes, option.Value.ctypesType())
+				}
+			}
+		case argument.Type.kind == anonymousStructKind:
+			for _, field := range argument.Type.fields {
+				argumentTypes = append(argumentTypes, field.Type.ctypesType())
+			}
+		case !argument.Type.hidden():
+			argumentTypes = append(argumentTypes, argument.Type.ctypesType())
+		}
+	}
+	for _, result := range call.Results {
+		if result.kind == anonymousStructKind {
+			for _, field := range result.fields {
+				argumentTypes = append(argumentTypes, fmt.Sprintf("ctypes.POINTER(%s)", field.Type.ctypesResultType()))
+			}
+			continue
+		}
+		argumentTypes = append(argumentTypes, fmt.Sprintf("ctypes.POINTER(%s)", result.ctypesResultType()))
+	}
+
+	fmt.Fprintf(declarations, "_lib.%s.argtypes = [%s]\n", call.Symbol, strings.Join(argumentTypes, ", "))
+	fmt.Fprintf(declarations, "_lib.%s.restype = ctypes.c_void_p\n\n", call.Symbol)
+}
+
+// writePythonCall renders a Python function, or a method when receiver is
+// set, calling the exported bridge function of a routine. Async calls are
+// coroutines running the bridge function in a worker thread.
+func writePythonCall(definitions *strings.Builder, indent string, call bridgeCall, receiver bool) {
+	parameters, arguments := pythonArguments(call)
+	if receiver {
+		parameters = append([]string{"self"}, parameters...)
+		arguments = append([]string{"self._handle"}, arguments...)
+	}
+
+	writeDeprecated(definitions, indent, call.Deprecated)
+	def := "def"
+	if call.Async {
+		def = "async def"
+	}
+	fmt.Fprintf(definitions, "%s%s %s(%s) -> %s:\n", indent, def, call.Name, strings.Join(parameters, ", "), pythonReturnType(call.Results))
+	bodyIndent := indent + pythonIndent
+	writeDocstring(definitions, bodyIndent, callDocstring(call, true))
+	writeDeprecationWarning(definitions, bodyIndent, call.Name, call.Deprecated)
+
+	returnValues := make([]string, 0, len(call.Results))
+	for index, result := range call.Results {
+		if result.kind == anonymousStructKind {
+			items := make([]string, 0, len(result.fields))
+			for field, parameter := range result.fieldParameters(fmt.Sprintf("_out%d", index)) {
+				fmt.Fprintf(definitions, "%s%s = %s()\n", bodyIndent, parameter, result.fields[field].Type.ctypesResultType())
+				arguments = append(arguments, fmt.Sprintf("ctypes.byref(%s)", parameter))
+				items = append(items, fmt.Sprintf("%q: %s", result.fields[field].Name, result.fields[field].Type.toPython(parameter+".value")))
+			}
+			returnValues = append(returnValues, "{"+strings.Join(items, ", ")+"}")
+			continue
+		}
+		fmt.Fprintf(definitions, "%s_out%d = %s()\n", bodyIndent, index, result.ctypesResultType())
+		arguments = append(arguments, fmt.Sprintf("ctypes.byref(_out%d)", index))
+		returnValues = append(returnValues, result.toPython(fmt.Sprintf("_out%d.value", index)))
+	}
+	if call.Async {
+		fmt.Fprintf(definitions, "%s_check(await asyncio.to_thread(%s))\n", bodyIndent, strings.Join(append([]string{"_lib." + call.Symbol}, arguments...), ", "))
+	} else {
+		fmt.Fprintf(definitions, "%s_check(_lib.%s(%s))\n", bodyIndent, call.Symbol, strings.Join(arguments, ", "))
+	}
+
+	switch len(returnValues) {
+	case 0:
+	case 1:
+		fmt.Fprintf(definitions, "%sreturn %s\n", bodyIndent, returnValues[0])
+	default:
+		fmt.Fprintf(definitions, "%sreturn (%s)\n", bodyIndent, strings.Join(returnValues, ", "))
+	}
+	if indent == "" {
+		definitions.WriteString("\n\n")
+	} else {
+		definitions.WriteString("\n")
+	}
+}
+
+// pythonArguments are the parameters of the Python function calling a
+// routine and the ctypes arguments it passes to the bridge.
+func pythonArguments(call bridgeCall) (parameters, arguments []string) {
+	for _, argument := range call.Arguments {
+		if argument.Type.hidden() {
+			continue
+		}
+		if argument.Type.kind == variadicKind {
+			element := argument.Type.element
+			values := argument.Name
+			if conversion := element.fromPython("value"); conversion != "value" {
+				values = fmt.Sprintf("[%s for value in %s]", conversion, argument.Name)
+			}
+			parameters = append(parameters, fmt.Sprintf("*%s: %s", argument.Name, element.pythonType()))
+			arguments = append(arguments, fmt.Sprintf("(%s * len(%s))(*%s)", element.ctypesType(), argument.Name, values), fmt.Sprintf("len(%s)", argument.Name))
+			continue
+		}
+		if argument.Type.kind == optionsKind {
+			parameters = append(parameters, "*")
+			for _, option := range argument.Type.options {
+				if option.Value == nil {
+					parameters = append(parameters, fmt.Sprintf("%s: bool = False", option.Keyword))
+					arguments = append(arguments, option.Keyword)
+					continue
+				}
+				parameters = append(parameters, fmt.Sprintf("%s: %s | None = None", option.Keyword, option.Value.pythonType()))
+				arguments = append(arguments,
+					fmt.Sprintf("%s is not None", option.Keyword),
+					fmt.Sprintf("%s if %s is not None else %s()", option.Value.fromPython(option.Keyword), option.Keyword, option.Value.ctypesType()))
+			}
+			continue
+		}
+		parameters = append(parameters, fmt.Sprintf("%s: %s", argument.Name, argument.Type.pythonType()))
+		if argument.Type.kind == anonymousStructKind {
+			for _, field := range argument.Type.fields {
+				arguments = append(arguments, field.Type.fromPython(fmt.Sprintf("%s[%q]", argument.Name, field.Name)))
+			}
+			continue
+		}
+		arguments = append(arguments, argument.Type.fromPython(argument.Name))
+	}
+	return parameters, arguments
+}
+
+// writeClass renders the Python class wrapping a handle to an exported struct,
+// with a property per exported field and a method per exported method.
+func (context *generatorContext) writeClass(declarations, definitions *strings.Builder, exportedStruct ExportedStruct) {
+	methods := []bridgeCall{}
+	closable, async := false, false
+	for _, method := range exportedStruct.Methods {
+		call, ok := context.resolveRoutine(method, context.symbol(exportedStruct.Name, method.Name))
+		if !ok {
+			continue
+		}
+		async = async || call.Async
+		// Close is wrapped by Closable, which releases the handle once closed.
+		if call.GoName == "Close" && !call.Async && len(call.Arguments) == 0 && len(call.Results) == 0 {
+			call.Name = "_close"
+			closable = true
+		}
+		methods = append(methods, call)
+	}
+
+	// Only the values used from coroutines are closed by async with.
+	base := "GoObject"
+	switch {
+	case closable && async:
+		base = "AsyncClosable"
+	case closable:
+		base = "Closable"
+	}
+	deprecated := deprecationMessage(exportedStruct.Doc)
+	writeDeprecated(definitions, "", deprecated)
+	fmt.Fprintf(definitions, "class %s(%s):\n", exportedStruct.Name, base)
+	writeClassDocstring(definitions, context.pythonDoc(exportedStruct.Doc))
+
+	properties := context.exportedFields(exportedStruct)
+	fields := []bridgeArgument{}
+	names := []string{}
+	for _, field := range properties {
+		names = append(names, fmt.Sprintf("%q", field.PythonName))
+		if !field.ReadOnly {
+			fields = append(fields, bridgeArgument{Name: field.PythonName, Type: field.Bridge})
+		}
+	}
+	if len(names) > 0 {
+		fmt.Fprintf(definitions, "%s_fields = (%s,)\n\n", pythonIndent, strings.Join(names, ", "))
+	}
+
+	constructor, variants := context.constructors(exportedStruct)
+	if constructor != nil {
+		// Building a deprecated struct is deprecated, whatever its constructor.
+		if constructor.Deprecated == "" && deprecated != "" {
+			constructor.Deprecated = deprecated
+		}
+		writeCallDeclaration(declarations, *constructor, false)
+		writeConstructor(definitions, exportedStruct.Name, *constructor)
+	} else {
+		context.writeFieldsConstructor(declarations, definitions, exportedStruct, fields)
+	}
+	for _, variant := range variants {
+		writeCallDeclaration(declarations, variant, false)
+		writeConstructor(definitions, exportedStruct.Name, variant)
+	}
+
+	for _, field := range properties {
+		getter := bridgeCall{
+			Name:       field.PythonName,
+			Symbol:     context.symbol(exportedStruct.Name, "get", field.Name),
+			Results:    []bridgeType{field.Bridge},
+			Doc:        context.pythonDoc(field.Doc),
+			Deprecated: deprecationMessage(field.Doc),
+		}
+		writeCallDeclaration(declarations, getter, true)
+		fmt.Fprintf(definitions, "%s@property\n", pythonIndent)
+		writePythonCall(definitions, pythonIndent, getter, true)
+		if field.ReadOnly {
+			continue
+		}
+
+		setter := bridgeCall{
+			Name:       field.PythonName,
+			Symbol:     context.symbol(exportedStruct.Name, "set", field.Name),
+			Arguments:  []bridgeArgument{{Name: "value", Type: field.Bridge}},
+			Deprecated: getter.Deprecated,
+		}
+		writeCallDeclaration(declarations, setter, true)
+		fmt.Fprintf(definitions, "%s@%s.setter\n", pythonIndent, field.PythonName)
+		writePythonCall(definitions, pythonIndent, setter, true)
+	}
+
+	for _, call := range methods {
+		writeCallDeclaration(declarations, call, true)
+		writePythonCall(definitions, pythonIndent, call, true)
+		writeDunderMethod(definitions, exportedStruct.Name, call)
+	}
+	if close := context.styledName("Close"); closable && close != "Close" {
+		fmt.Fprintf(definitions, "%s%s = Closable.Close\n\n", pythonIndent, close)
+	}
+
+	trimTrailingBlankLines(definitions)
+	definitions.WriteString("\n\n")
+}
+
+// writeAliases binds the Go type aliases to the Python class or type they
+// name, so that both names refer to the same class object.
+func (context *generatorContext) writeAliases(definitions *strings.Builder) {
+	written := false
+	for _, alias := range context.objects.ExportedAliases {
+		aliasType := context.resolveType(alias.Type)
+		if !aliasType.aliasable() {
+			log.Printf("Skipping alias %s: unsupported type %s", alias.Name, alias.Type)
+			continue
+		}
+		fmt.Fprintf(definitions, "%s = %s\n", alias.Name, aliasType.pythonType())
+		writeDocstring(definitions, "", context.pythonDoc(alias.Doc))
+		written = true
+	}
+	if written {
+		definitions.WriteString("\n\n")
+	}
+}
+
+// writeGenericDispatch renders a generic function calling the instantiation
+// matching the classes of its generic arguments, typed with an overload per
+// instantiation. Instantiations only differing by their results can only be
+// called by their own names.
+func (context *generatorContext) writeGenericDispatch(definitions *strings.Builder, generic genericFunction) {
+	name := context.pythonRoutineName(generic.Generic)
+	overloads := &strings.Builder{}
+	instances := []string{}
+	resolved := 0
+	for _, instanceName := range generic.Instances {
+		function := findFunctionByName(context.objects.ExportedFunctions, instanceName)
+		call, ok := context.resolveRoutine(*function, context.symbol(instanceName))
+		if !ok {
+			continue
+		}
+		resolved++
+
+		checks := []string{}
+		for index, argument := range call.Arguments {
+			if argument.Type.hidden() || !mentionsTypeParameter(generic.Generic.Arguments[index].Type, generic.Generic.TypeParameters) {
+				continue
+			}
+			if classes := argument.Type.pythonClasses(); classes != "" {
+				checks = append(checks, fmt.Sprintf("%q: (%s,)", argument.Name, classes))
+			}
+		}
+		if len(checks) == 0 {
+			continue
+		}
+
+		parameters, _ := pythonArguments(call)
+		fmt.Fprintf(overloads, "@typing.overload\ndef %s(%s) -> %s: ...\n\n\n", name, strings.Join(parameters, ", "), pythonReturnType(call.Results))
+		instances = append(instances, fmt.Sprintf("(%s, {%s})", call.Name, strings.Join(checks, ", ")))
+	}
+	if len(instances) == 0 {
+		if resolved > 0 {
+			log.Printf("Skipping dispatch of generic function %s: its instantiations cannot be told apart by their arguments", name)
+		}
+		return
+	}
+
+	definitions.WriteString(overloads.String())
+	deprecated := deprecationMessage(generic.Generic.Doc)
+	writeDeprecated(definitions, "", deprecated)
+	fmt.Fprintf(definitions, "def %s(*args: typing.Any, **kwargs: typing.Any) -> typing.Any:\n", name)
+	writeDocstring(definitions, pythonIndent, context.pythonDoc(generic.Generic.Doc))
+	writeDeprecationWarning(definitions, pythonIndent, name, deprecated)
+	fmt.Fprintf(definitions, "%sreturn _dispatch(%q, [%s], args, kwargs)\n\n\n", pythonIndent, name, strings.Join(instances, ", "))
+}
+
+// writeDunderMethod wires the Python special method matching the shape of a
+// well-known Go method, such as __str__ for String() string, delegating to
+// the method rendered under its Python name. Coroutines are not wired.
+// Classes comparing with Equal are unhashable, like the mutable builtins,
+// since nothing tells how Go would hash equal values alike.
+func writeDunderMethod(definitions *strings.Builder, className string, call bridgeCall) {
+	arguments, results := call.Arguments, call.Results
+	sameClass := len(arguments) == 1 && (arguments[0].Type.kind == structKind || arguments[0].Type.kind == structPointerKind) && arguments[0].Type.name == className
+	returns := func(kind typeKind) bool {
+		return len(results) == 1 && results[0].kind == kind
+	}
+
+	if call.Async {
+		return
+	}
+	method := "self." + call.Name
+	lines := []string{}
+	switch {
+	case call.GoName == "String" && len(arguments) == 0 && returns(stringKind):
+		lines = []string{"def __str__(self) -> str:", "    return " + method + "()"}
+	case call.GoName == "Len" && len(arguments) == 0 && returns(intKind):
+		lines = []string{"def __len__(self) -> int:", "    return " + method + "()"}
+	case call.GoName == "Equal" && sameClass && returns(boolKind):
+		lines = []string{
+			"def __eq__(self, other: object) -> bool:",
+			fmt.Sprintf("    if not isinstance(other, %s):", className),
+			"        return NotImplemented",
+			"    return " + method + "(other)",
+			"",
+			"__hash__ = None  # type: ignore[assignment]",
+		}
+	case call.GoName == "Less" && sameClass && returns(boolKind):
+		lines = []string{
+			fmt.Sprintf("def __lt__(self, other: %s) -> bool:", className),
+			fmt.Sprintf("    if not isinstance(other, %s):", className),
+			"        return NotImplemented",
+			"    return " + method + "(other)",
+		}
+	case call.GoName == "Get" && len(arguments) == 1 && len(results) == 1:
+		lines = []string{
+			fmt.Sprintf("def __getitem__(self, key: %s) -> %s:", arguments[0].Type.pythonType(), results[0].pythonType()),
+			"    return " + method + "(key)",
+		}
+	case call.GoName == "Get" && len(arguments) == 1 && len(results) == 2 && results[1].kind == boolKind:
+		lines = []string{
+			fmt.Sprintf("def __getitem__(self, key: %s) -> %s:", arguments[0].Type.pythonType(), results[0].pythonType()),
+			"    value, ok = " + method + "(key)",
+			"    if not ok:",
+			"        raise KeyError(key)",
+			"    return value",
+		}
+	case call.GoName == "Set" && len(arguments) == 2 && len(results) == 0:
+		lines = []string{
+			fmt.Sprintf("def __setitem__(self, key: %s, value: %s) -> None:", arguments[0].Type.pythonType(), arguments[1].Type.pythonType()),
+			"    " + method + "(key, value)",
+		}
+	case call.GoName == "All" && len(arguments) == 0 && returns(sequenceKind) && results[0].key == nil:
+		lines = []string{
+			fmt.Sprintf("def __iter__(self) -> typing.Iterator[%s]:", results[0].element.pythonType()),
+			"    return " + method + "()",
+		}
+	}
+
+	for _, line := range lines {
+		if line == "" {
+			definitions.WriteString("\n")
+			continue
+		}
+		fmt.Fprintf(definitions, "%s%s\n", pythonIndent, line)
+	}
+	if len(lines) > 0 {
+		definitions.WriteString("\n")
+	}
+}
+
+// constructors resolves the NewX functions building a struct: the primary
+// constructor used as __init__, if any, and the variants exposed as class
+// methods named after their suffix, such as from_file for NewXFromFile.
+func (context *generatorContext) constructors(exportedStruct ExportedStruct) (constructor *bridgeCall, variants []bridgeCall) {
+	for _, function := range context.objects.ExportedFunctions {
+		structName, variant, ok := context.constructorOf(function)
+		if !ok || structName != exportedStruct.Name {
+			continue
+		}
+		call, ok := context.resolveRoutine(function, context.symbol(function.Name))
+		if !ok {
+			log.Printf("Skipping constructor %s: unsupported types", function.Name)
+			continue
+		}
+		if variant == "" {
+			call.Name = "__init__"
+			constructor = &call
+			continue
+		}
+		call.Name = argumentName(snakeCase(variant), 0)
+		variants = append(variants, call)
+	}
+	return constructor, variants
+}
+
+// writeConstructor renders a constructor function as the __init__ method or,
+// for the variants, as a class method of the class it builds.
+func writeConstructor(definitions *strings.Builder, className string, call bridgeCall) {
+	parameters, arguments := pythonArguments(call)
+	arguments = append(arguments, "ctypes.byref(handle)")
+	bodyIndent := pythonIndent + pythonIndent
+
+	if call.Name == "__init__" {
+		writeDeprecated(definitions, pythonIndent, call.Deprecated)
+		fmt.Fprintf(definitions, "%sdef __init__(%s) -> None:\n", pythonIndent, strings.Join(append([]string{"self"}, parameters...), ", "))
+	} else {
+		fmt.Fprintf(definitions, "%s@classmethod\n", pythonIndent)
+		writeDeprecated(definitions, pythonIndent, call.Deprecated)
+		fmt.Fprintf(definitions, "%sdef %s(%s) -> typing.Self:\n", pythonIndent, call.Name, strings.Join(append([]string{"cls"}, parameters...), ", "))
+	}
+	writeDocstring(definitions, bodyIndent, callDocstring(call, false))
+	if call.Name == "__init__" {
+		writeDeprecationWarning(definitions, bodyIndent, className, call.Deprecated)
+	} else {
+		writeDeprecationWarning(definitions, bodyIndent, className+"."+call.Name, call.Deprecated)
+	}
+	fmt.Fprintf(definitions, "%shandle = ctypes.c_size_t()\n", bodyIndent)
+	fmt.Fprintf(definitions, "%s_check(_lib.%s(%s))\n", bodyIndent, call.Symbol, strings.Join(arguments, ", "))
+	if call.Name == "__init__" {
+		fmt.Fprintf(definitions, "%sself._handle = handle.value\n\n", bodyIndent)
+	} else {
+		fmt.Fprintf(definitions, "%sreturn cls._from_handle(handle.value)\n\n", bodyIndent)
+	}
+}
+
+// writeFieldsConstructor renders the __init__ method of structs without
+// constructor, building the zero value and assigning the given fields.
+func (context *generatorContext) writeFieldsConstructor(declarations, definitions *strings.Builder, exportedStruct ExportedStruct, fields []bridgeArgument) {
+	newSymbol := context.symbol(exportedStruct.Name, "new")
+	fmt.Fprintf(declarations, "_lib.%s.argtypes = [ctypes.POINTER(ctypes.c_size_t)]\n", newSymbol)
+	fmt.Fprintf(declarations, "_lib.%s.restype = ctypes.c_void_p\n\n", newSymbol)
+
+	parameters := []string{"self"}
+	if len(fields) > 0 {
+		parameters = append(parameters, "*")
+	}
+	for _, field := range fields {
+		parameters = append(parameters, fmt.Sprintf("%s: %s | None = None", field.Name, field.Type.pythonType()))
+	}
+	deprecated := deprecationMessage(exportedStruct.Doc)
+	writeDeprecated(definitions, pythonIndent, deprecated)
+	fmt.Fprintf(definitions, "%sdef __init__(%s) -> None:\n", pythonIndent, strings.Join(parameters, ", "))
+	bodyIndent := pythonIndent + pythonIndent
+	writeDeprecationWarning(definitions, bodyIndent, exportedStruct.Name, deprecated)
+	fmt.Fprintf(definitions, "%shandle = ctypes.c_size_t()\n", bodyIndent)
+	fmt.Fprintf(definitions, "%s_check(_lib.%s(ctypes.byref(handle)))\n", bodyIndent, newSymbol)
+	fmt.Fprintf(definitions, "%sself._handle = handle.value\n", bodyIndent)
+	for _, field := range fields {
+		fmt.Fprintf(definitions, "%sif %s is not None:\n", bodyIndent, field.Name)
+		fmt.Fprintf(definitions, "%s%sself.%s = %s\n", bodyIndent, pythonIndent, field.Name, field.Name)
+	}
+	definitions.WriteString("\n")
+}
+
+// writeProtocol renders the typing.Protocol Python classes must satisfy to be
+// passed where Go expects the interface, and registers one ctypes callback per
+// method so that the Go proxy can call back into the Python object.
+func (context *generatorContext) writeProtocol(declarations, definitions *strings.Builder, exportedInterface ExportedInterface) {
+	definitions.WriteString("@typing.runtime_checkable\n")
+	writeDeprecated(definitions, "", deprecationMessage(exportedInterface.Doc))
+	fmt.Fprintf(definitions, "class %s(typing.Protocol):\n", exportedInterface.Name)
+	writeClassDocstring(definitions, context.pythonDoc(exportedInterface.Doc))
+	if len(exportedInterface.Methods) == 0 {
+		fmt.Fprintf(definitions, "%s...\n", pythonIndent)
+	}
+
+	callbacks := &strings.Builder{}
+	for _, method := range exportedInterface.Methods {
+		call, _ := context.resolveRoutine(method, context.symbol(exportedInterface.Name, method.Name))
+
+		parameters := []string{"self"}
+		for _, argument := range call.Arguments {
+			parameters = append(parameters, fmt.Sprintf("%s: %s", argument.Name, argument.Type.pythonType()))
+		}
+		writeDeprecated(definitions, pythonIndent, call.Deprecated)
+		fmt.Fprintf(definitions, "%sdef %s(%s) -> %s:\n", pythonIndent, call.Name, strings.Join(parameters, ", "), pythonReturnType(call.Results))
+		writeDocstring(definitions, pythonIndent+pythonIndent, callDocstring(call, true))
+		fmt.Fprintf(definitions, "%s...\n\n", pythonIndent+pythonIndent)
+
+		writeCallback(callbacks, fmt.Sprintf("_%s_%s", exportedInterface.Name, call.GoName), "_deref(ref)."+call.Name, call)
+	}
+
+	trimTrailingBlankLines(definitions)
+	definitions.WriteString("\n\n")
+	definitions.WriteString(callbacks.String())
+}
+
+// writeCallback renders the ctypes callback through which Go calls the target
+// on the Python object registered under a reference, and registers it.
+func writeCallback(callbacks *strings.Builder, callbackName, target string, call bridgeCall) {
+	callbackTypes := []string{"ctypes.c_size_t"}
+	parameters := []string{"ref"}
+	arguments := []string{}
+	for index, argument := range call.Arguments {
+		callbackTypes = append(callbackTypes, argument.Type.ctypesType())
+		parameters = append(parameters, fmt.Sprintf("in%d", index))
+		arguments = append(arguments, callbackArgument(argument.Type, fmt.Sprintf("in%d", index)))
+	}
+	for index, result := range call.Results {
+		callbackTypes = append(callbackTypes, fmt.Sprintf("ctypes.POINTER(%s)", result.ctypesResultType()))
+		parameters = append(parameters, fmt.Sprintf("out%d", index))
+	}
+	callbackTypes = append(callbackTypes, "ctypes.POINTER(ctypes.c_void_p)")
+	parameters = append(parameters, "failure")
+
+	fmt.Fprintf(callbacks, "%s_type = ctypes.CFUNCTYPE(None, %s)\n\n\n", callbackName, strings.Join(callbackTypes, ", "))
+	fmt.Fprintf(callbacks, "@%s_type\ndef %s(%s):\n", callbackName, callbackName, strings.Join(parameters, ", "))
+	fmt.Fprintf(callbacks, "%stry:\n", pythonIndent)
+
+	bodyIndent := pythonIndent + pythonIndent
+	invocation := fmt.Sprintf("%s(%s)", target, strings.Join(arguments, ", "))
+	switch len(call.Results) {
+	case 0:
+		fmt.Fprintf(callbacks, "%s%s\n", bodyIndent, invocation)
+	case 1:
+		fmt.Fprintf(callbacks, "%sresult = %s\n", bodyIndent, invocation)
+		fmt.Fprintf(callbacks, "%sout0[0] = %s\n", bodyIndent, call.Results[0].pythonString("result"))
+	default:
+		fmt.Fprintf(callbacks, "%sresult = %s\n", bodyIndent, invocation)
+		for index, result := range call.Results {
+			fmt.Fprintf(callbacks, "%sout%d[0] = %s\n", bodyIndent, index, result.pythonString(fmt.Sprintf("result[%d]", index)))
+		}
+	}
+	fmt.Fprintf(callbacks, "%sexcept Exception as error:\n", pythonIndent)
+	fmt.Fprintf(callbacks, "%sfailure[0] = _lib.melo_string(str(error).encode())\n\n\n", bodyIndent)
+
+	fmt.Fprintf(callbacks, "_lib.%s_register.argtypes = [%s_type]\n", call.Symbol, callbackName)
+	fmt.Fprintf(callbacks, "_lib.%s_register.restype = None\n", call.Symbol)
+	fmt.Fprintf(callbacks, "_lib.%s_register(%s)\n\n\n", call.Symbol, callbackName)
+}
+
+// writeReceiverClass renders the ReceiveChannel subclass for the channels of
+// one element type.
+func writeReceiverClass(declarations, definitions *strings.Builder, channel bridgeType) {
+	receive := bridgeCall{
+		Name:    "_receive",
+		Symbol:  channel.symbol + "_next",
+		Results: []bridgeType{*channel.element, {kind: boolKind, goType: "bool"}},
+	}
+	closing := bridgeCall{
+		Name:   "_close",
+		Symbol: channel.symbol + "_close",
+	}
+	writeCallDeclaration(declarations, receive, true)
+	writeCallDeclaration(declarations, closing, true)
+
+	fmt.Fprintf(definitions, "class %s(ReceiveChannel[%s]):\n", receiverClassName(channel), channel.element.pythonType())
+	writePythonCall(definitions, pythonIndent, receive, true)
+	writePythonCall(definitions, pythonIndent, closing, true)
+	trimTrailingBlankLines(definitions)
+	definitions.WriteString("\n\n")
+}
+
+// writeSequenceClass renders the Sequence subclass for the sequences of one
+// element type.
+func writeSequenceClass(declarations, definitions *strings.Builder, sequence bridgeType) {
+	pull := bridgeCall{
+		Name:    "_pull",
+		Symbol:  sequence.symbol + "_pull",
+		Results: sequence.pullResults(),
+	}
+	stop := bridgeCall{
+		Name:   "_stop",
+		Symbol: sequence.symbol + "_stop",
+	}
+	writeCallDeclaration(declarations, pull, true)
+	writeCallDeclaration(declarations, stop, true)
+
+	fmt.Fprintf(definitions, "class %s(Sequence[%s]):\n", sequenceClassName(sequence), sequence.sequenceValueType())
+	writePythonCall(definitions, pythonIndent, pull, true)
+	writePythonCall(definitions, pythonIndent, stop, true)
+	trimTrailingBlankLines(definitions)
+	definitions.WriteString("\n\n")
+}
+
+// writeHandlerClass renders the Handler subclass serving requests through the
+// bridge of the package.
+func writeHandlerClass(declarations, definitions *strings.Builder, handler bridgeType) {
+	fmt.Fprintf(declarations, "_lib.%s_serve.argtypes = [ctypes.c_size_t, ctypes.c_char_p, ctypes.c_char_p, ctypes.c_char_p, ctypes.c_char_p, ctypes.c_size_t, ctypes.c_char_p, ctypes.POINTER(ctypes.c_longlong), ctypes.POINTER(ctypes.c_void_p), ctypes.POINTER(ctypes.c_void_p), ctypes.POINTER(ctypes.c_size_t)]\n", handler.symbol)
+	fmt.Fprintf(declarations, "_lib.%s_serve.restype = ctypes.c_void_p\n\n", handler.symbol)
+
+	definitions.WriteString("class _Handler(Handler):\n")
+	fmt.Fprintf(definitions, "%sdef _serve(self, *arguments) -> None:\n", pythonIndent)
+	fmt.Fprintf(definitions, "%s%s_check(_lib.%s_serve(self._handle, *arguments))\n\n\n", pythonIndent, pythonIndent, handler.symbol)
+}
+
+// writeFunctionClass renders the callable wrapping Go functions of one func
+// type, and the callback through which Go calls Python callables of that type.
+func writeFunctionClass(declarations, definitions *strings.Builder, function bridgeType) {
+	call := *function.signature
+	call.Name = "__call__"
+	call.Symbol = function.symbol + "_call"
+	writeCallDeclaration(declarations, call, true)
+
+	fmt.Fprintf(definitions, "class %s(GoObject):\n", functionClassName(function))
+	writePythonCall(definitions, pythonIndent, call, true)
+	trimTrailingBlankLines(definitions)
+	definitions.WriteString("\n\n")
+
+	callback := *function.signature
+	callback.Symbol = function.symbol
+	writeCallback(definitions, "_callback_"+function.name, "_deref(ref)", callback)
+}
+
+// writeTypedDict renders the TypedDict standing for an anonymous struct.
+func writeTypedDict(definitions *strings.Builder, anonymous bridgeType) {
+	fmt.Fprintf(definitions, "class %s(typing.TypedDict):\n", anonymous.name)
+	for _, field := range anonymous.fields {
+		fmt.Fprintf(definitions, "%s%s: %s\n", pythonIndent, field.Name, field.Type.pythonType())
+	}
+	definitions.WriteString("\n\n")
+}
+
+// writeFeederCallback renders the callback the Go feeder goroutine uses to
+// pull the next value from a Python iterator.
+func writeFeederCallback(definitions *strings.Builder, channel bridgeType) {
+	callbackName := feederCallbackName(channel)
+	bodyIndent := pythonIndent + pythonIndent
+
+	fmt.Fprintf(definitions, "%s_type = ctypes.CFUNCTYPE(None, ctypes.c_size_t, ctypes.POINTER(%s), ctypes.POINTER(ctypes.c_bool), ctypes.POINTER(ctypes.c_void_p))\n\n\n", callbackName, channel.element.ctypesResultType())
+	fmt.Fprintf(definitions, "@%s_type\ndef %s(ref, out0, out1, failure):\n", callbackName, callbackName)
+	fmt.Fprintf(definitions, "%stry:\n", pythonIndent)
+	fmt.Fprintf(definitions, "%sout0[0] = %s\n", bodyIndent, channel.element.pythonString("next(_deref(ref))"))
+	fmt.Fprintf(definitions, "%sout1[0] = True\n", bodyIndent)
+	fmt.Fprintf(definitions, "%sexcept StopIteration:\n", pythonIndent)
+	fmt.Fprintf(definitions, "%sout1[0] = False\n", bodyIndent)
+	fmt.Fprintf(definitions, "%sexcept Exception as error:\n", pythonIndent)
+	fmt.Fprintf(definitions, "%sfailure[0] = _lib.melo_string(str(error).encode())\n\n\n", bodyIndent)
+
+	fmt.Fprintf(definitions, "_lib.%s_register.argtypes = [%s_type]\n", channel.symbol, callbackName)
+	fmt.Fprintf(definitions, "_lib.%s_register.restype = None\n", channel.symbol)
+	fmt.Fprintf(definitions, "_lib.%s_register(%s)\n\n\n", channel.symbol, callbackName)
+}
+
+// callbackArgument converts a value received by a ctypes callback. Unlike
+// results, strings received by callbacks are owned by Go, and numbers sent as
+// text arrive as bytes.
+func callbackArgument(argument bridgeType, expression string) string {
+	if argument.kind == stringKind {
+		return fmt.Sprintf("%s.decode()", expression)
+	}
+	return argument.toPython(expression)
+}
+
+func pythonReturnType(results []bridgeType) string {
+	switch len(results) {
+	case 0:
+		return "None"
+	case 1:
+		return results[0].pythonResultType()
+	}
+	resultTypes := make([]string, 0, len(results))
+	for _, result := range results {
+		resultTypes = append(resultTypes, result.pythonResultType())
+	}
+	return fmt.Sprintf("tuple[%s]", strings.Join(resultTypes, ", "))
+}
+
+func writeDocstring(builder *strings.Builder, indent, doc string) {
+	doc = strings.TrimSpace(doc)
+	if doc == "" {
+		return
+	}
+	doc = strings.ReplaceAll(doc, "\\", "\\\\")
+	// A quote ending the doc would run into the closing quotes.
+	if trimmed, ok := strings.CutSuffix(doc, "\""); ok {
+		doc = trimmed + "\\\""
+	}
+	doc = strings.ReplaceAll(doc, "\"\"\"", "\\\"\\\"\\\"")
+
+	lines := strings.Split(doc, "\n")
+	if len(lines) == 1 {
+		fmt.Fprintf(builder, "%s\"\"\"%s\"\"\"\n", indent, lines[0])
+		return
+	}
+
+	fmt.Fprintf(builder, "%s\"\"\"%s\n", indent, lines[0])
+	for _, line := range lines[1:] {
+		if line == "" {
+			builder.WriteString("\n")
+			continue
+		}
+		fmt.Fprintf(builder, "%s%s\n", indent, line)
+	}
+	fmt.Fprintf(builder, "%s\"\"\"\n", indent)
+}
+
+func writeClassDocstring(builder *strings.Builder, doc string) {
+	if strings.TrimSpace(doc) == "" {
+		return
+	}
+	writeDocstring(builder, pythonIndent, doc)
+	builder.WriteString("\n")
+}
+
+func trimTrailingBlankLines(builder *strings.Builder) {
+	content := strings.TrimRight(builder.String(), "\n") + "\n"
+	builder.Reset()
+	builder.WriteString(content)
+}
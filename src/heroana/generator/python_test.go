@@ -0,0 +1,1044 @@
+package generator_test
+
+import (
+	"bytes"
+	"go/parser"
+	"go/token"
+	"log"
+	"math"
+	"math/big"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/EdmilsonRodrigues/melo-project/src/melo/files"
+	"github.com/EdmilsonRodrigues/melo-project/src/melo/generator"
+)
+
+func TestGeneratePythonModule(t *testing.T) {
+	module, err := generator.GeneratePythonModule(greeterPackage, greeterObjects)
+	if err != nil {
+		t.Fatalf("GeneratePythonModule should not return error, got %v", err)
+	}
+
+	t.Run("should render a protocol per interface", func(t *testing.T) {
+		for _, expected := range []string{
+			"@typing.runtime_checkable\nclass Greeter(typing.Protocol):\n    \"\"\"Greeter greets people.\"\"\"\n",
+			"    def SayHello(self, name: str) -> str:\n        ...\n",
+			"    def Count(self, n: int) -> int:\n        ...\n",
+		} {
+			if !strings.Contains(module, expected) {
+				t.Errorf("GeneratePythonModule should contain %q, got\n%s", expected, module)
+			}
+		}
+	})
+
+	t.Run("should register a callback per interface method", func(t *testing.T) {
+		for _, expected := range []string{
+			"@_Greeter_SayHello_type\ndef _Greeter_SayHello(ref, in0, out0, failure):\n",
+			"        result = _deref(ref).SayHello(in0.decode())\n        out0[0] = _lib.melo_string(result.encode())\n",
+			"_lib.melo_mypackage_greet_Greeter_Count_register(_Greeter_Count)\n",
+		} {
+			if !strings.Contains(module, expected) {
+				t.Errorf("GeneratePythonModule should contain %q, got\n%s", expected, module)
+			}
+		}
+	})
+
+	t.Run("should pass Python objects as references to functions accepting the interface", func(t *testing.T) {
+		expected := "def Greet(greeter: Greeter, person: Person) -> str:\n" +
+			"    \"\"\"Greet greets a person.\n\n    Args:\n        greeter (Greeter)\n        person (Person)\n\n    Returns:\n        str\n\n    Raises:\n        GoError: If the Go call returns an error.\n    \"\"\"\n" +
+			"    _out0 = ctypes.c_void_p()\n" +
+			"    _check(_lib.melo_mypackage_greet_Greet(_ref(greeter), person._handle, ctypes.byref(_out0)))\n" +
+			"    return _string(_out0.value)\n"
+		if !strings.Contains(module, expected) {
+			t.Errorf("GeneratePythonModule should contain %q, got\n%s", expected, module)
+		}
+	})
+
+	t.Run("should accept variadic arguments as *args", func(t *testing.T) {
+		expected := "def GreetAll(greeting: str, *people: Person) -> str:\n" +
+			"    _out0 = ctypes.c_void_p()\n" +
+			"    _check(_lib.melo_mypackage_greet_GreetAll(greeting.encode(), (ctypes.c_size_t * len(people))(*[value._handle for value in people]), len(people), ctypes.byref(_out0)))\n"
+		if !strings.Contains(module, expected) {
+			t.Errorf("GeneratePythonModule should contain %q, got\n%s", expected, module)
+		}
+		declaration := "_lib.melo_mypackage_greet_GreetAll.argtypes = [ctypes.c_char_p, ctypes.POINTER(ctypes.c_size_t), ctypes.c_size_t, ctypes.POINTER(ctypes.c_void_p)]\n"
+		if !strings.Contains(module, declaration) {
+			t.Errorf("GeneratePythonModule should contain %q, got\n%s", declaration, module)
+		}
+	})
+
+	t.Run("should render a class per struct", func(t *testing.T) {
+		for _, expected := range []string{
+			"class Person(GoObject):\n    _fields = (\"Name\",)\n\n    def __init__(self, *, Name: str | None = None) -> None:\n",
+			"    @property\n    def Name(self) -> str:\n",
+			"    @Name.setter\n    def Name(self, value: str) -> None:\n",
+			"    def Describe(self, prefix: str) -> str:\n",
+		} {
+			if !strings.Contains(module, expected) {
+				t.Errorf("GeneratePythonModule should contain %q, got\n%s", expected, module)
+			}
+		}
+	})
+
+	t.Run("should skip interfaces that cannot be proxied", func(t *testing.T) {
+		for _, unexpected := range []string{"class Unsupported", "def UseUnsupported"} {
+			if strings.Contains(module, unexpected) {
+				t.Errorf("GeneratePythonModule should not contain %q, got\n%s", unexpected, module)
+			}
+		}
+	})
+}
+
+func TestGeneratePythonModuleChannels(t *testing.T) {
+	module, err := generator.GeneratePythonModule(greeterPackage, channelObjects)
+	if err != nil {
+		t.Fatalf("GeneratePythonModule should not return error, got %v", err)
+	}
+
+	for _, expected := range []string{
+		"def Count(n: int) -> ReceiveChannel[int]:\n",
+		"    return _Receiver_int._from_handle(_out0.value)\n",
+		"class _Receiver_int(ReceiveChannel[int]):\n    def _receive(self) -> tuple[int, bool]:\n",
+		"def Join(names: typing.Iterable[str]) -> str:\n",
+		"_check(_lib.melo_mypackage_greet_Join(_ref(iter(names)), ctypes.byref(_out0)))",
+		"        out0[0] = _lib.melo_string(next(_deref(ref)).encode())\n        out1[0] = True\n    except StopIteration:\n        out1[0] = False\n",
+		"_lib.melo_mypackage_greet_feeder_string_register(_feeder_string)\n",
+		"def Produce(values: typing.Iterable[int]) -> None:\n",
+		"_check(_lib.melo_mypackage_greet_Produce(_ref(iter(values))))",
+	} {
+		if !strings.Contains(module, expected) {
+			t.Errorf("GeneratePythonModule should contain %q, got\n%s", expected, module)
+		}
+	}
+}
+
+func TestGeneratePythonModuleSequences(t *testing.T) {
+	module, err := generator.GeneratePythonModule(greeterPackage, sequenceObjects)
+	if err != nil {
+		t.Fatalf("GeneratePythonModule should not return error, got %v", err)
+	}
+
+	for _, expected := range []string{
+		"def Numbers(n: int) -> typing.Generator[int, None, None]:\n",
+		"    return iter(_Sequence_int._from_handle(_out0.value))\n",
+		"def Pairs() -> typing.Generator[tuple[str, float], None, None]:\n",
+		"class _Sequence_int(Sequence[int]):\n    def _pull(self) -> tuple[int, bool]:\n",
+		"class _Sequence_string_float64(Sequence[tuple[str, float]]):\n    def _pull(self) -> tuple[str, float, bool]:\n",
+		"    def _stop(self) -> None:\n        _check(_lib.melo_mypackage_greet_sequence_int_stop(self._handle))\n",
+	} {
+		if !strings.Contains(module, expected) {
+			t.Errorf("GeneratePythonModule should contain %q, got\n%s", expected, module)
+		}
+	}
+}
+
+func TestGeneratePythonModuleOptions(t *testing.T) {
+	module, err := generator.GeneratePythonModule(greeterPackage, optionObjects)
+	if err != nil {
+		t.Fatalf("GeneratePythonModule should not return error, got %v", err)
+	}
+
+	for _, expected := range []string{
+		"def Connect(addr: str, *, timeout: datetime.timedelta | None = None, max_retries: int | None = None, debug: bool = False) -> Client:\n",
+		"timeout is not None, timeout // datetime.timedelta(microseconds=1) * 1000 if timeout is not None else ctypes.c_longlong(), ",
+		"max_retries is not None, _bounded(max_retries, -9223372036854775808, 9223372036854775807) if max_retries is not None else ctypes.c_longlong(), debug, ctypes.byref(_out0)",
+		"_lib.melo_mypackage_greet_Connect.argtypes = [ctypes.c_char_p, ctypes.c_bool, ctypes.c_longlong, ctypes.c_bool, ctypes.c_longlong, ctypes.c_bool, ctypes.POINTER(ctypes.c_size_t)]\n",
+	} {
+		if !strings.Contains(module, expected) {
+			t.Errorf("GeneratePythonModule should contain %q, got\n%s", expected, module)
+		}
+	}
+}
+
+func TestGeneratePythonModuleConstructors(t *testing.T) {
+	objects := generator.ExportedObjects{
+		ExportedStructs: []generator.ExportedStruct{
+			{Name: "Client", Fields: []generator.ExportedField{{Name: "Addr", Type: "string"}}},
+			{Name: "Server", Fields: []generator.ExportedField{{Name: "Port", Type: "int"}}},
+		},
+		ExportedFunctions: []generator.ExportedRoutine{
+			{
+				Name:        "NewClient",
+				Arguments:   []generator.ExportedArgument{{Name: "addr", Type: "string"}},
+				ReturnTypes: []string{"*example.com/greet.Client", "error"},
+				Doc:         "NewClient connects to addr.",
+			},
+			{
+				Name:        "NewClientFromEnv",
+				ReturnTypes: []string{"example.com/greet.Client"},
+			},
+			{
+				Name:        "Newcomer",
+				ReturnTypes: []string{"*example.com/greet.Client"},
+			},
+		},
+	}
+	module, err := generator.GeneratePythonModule(greeterPackage, objects)
+	if err != nil {
+		t.Fatalf("GeneratePythonModule should not return error, got %v", err)
+	}
+
+	t.Run("should use the constructor as __init__", func(t *testing.T) {
+		expected := "    def __init__(self, addr: str) -> None:\n" +
+			"        \"\"\"NewClient connects to addr.\n\n        Args:\n            addr (str)\n\n        Raises:\n            GoError: If the Go call returns an error.\n        \"\"\"\n" +
+			"        handle = ctypes.c_size_t()\n" +
+			"        _check(_lib.melo_mypackage_greet_NewClient(addr.encode(), ctypes.byref(handle)))\n" +
+			"        self._handle = handle.value\n"
+		if !strings.Contains(module, expected) {
+			t.Errorf("GeneratePythonModule should contain %q, got\n%s", expected, module)
+		}
+	})
+
+	t.Run("should expose constructor variants as class methods", func(t *testing.T) {
+		expected := "    @classmethod\n    def from_env(cls) -> typing.Self:\n" +
+			"        handle = ctypes.c_size_t()\n" +
+			"        _check(_lib.melo_mypackage_greet_NewClientFromEnv(ctypes.byref(handle)))\n" +
+			"        return cls._from_handle(handle.value)\n"
+		if !strings.Contains(module, expected) {
+			t.Errorf("GeneratePythonModule should contain %q, got\n%s", expected, module)
+		}
+	})
+
+	t.Run("should keep constructors out of the module functions", func(t *testing.T) {
+		for _, unexpected := range []string{"def NewClient(", "def NewClientFromEnv(", "melo_mypackage_greet_Client_new"} {
+			if strings.Contains(module, unexpected) {
+				t.Errorf("GeneratePythonModule should not contain %q, got\n%s", unexpected, module)
+			}
+		}
+		if !strings.Contains(module, "def Newcomer() -> Client:\n") {
+			t.Errorf("GeneratePythonModule should keep Newcomer as a function, got\n%s", module)
+		}
+	})
+
+	t.Run("should keep field construction without constructor", func(t *testing.T) {
+		expected := "class Server(GoObject):\n    _fields = (\"Port\",)\n\n    def __init__(self, *, Port: int | None = None) -> None:\n"
+		if !strings.Contains(module, expected) {
+			t.Errorf("GeneratePythonModule should contain %q, got\n%s", expected, module)
+		}
+	})
+}
+
+func TestGeneratePythonModuleDunderMethods(t *testing.T) {
+	bag := "*example.com/greet.Bag"
+	objects := generator.ExportedObjects{
+		ExportedStructs: []generator.ExportedStruct{
+			{
+				Name: "Bag",
+				Methods: []generator.ExportedRoutine{
+					{Name: "String", ReturnTypes: []string{"string"}},
+					{Name: "Len", ReturnTypes: []string{"int"}},
+					{Name: "Equal", Arguments: []generator.ExportedArgument{{Name: "other", Type: bag}}, ReturnTypes: []string{"bool"}},
+					{Name: "Less", Arguments: []generator.ExportedArgument{{Name: "other", Type: bag}}, ReturnTypes: []string{"bool"}},
+					{Name: "Get", Arguments: []generator.ExportedArgument{{Name: "key", Type: "string"}}, ReturnTypes: []string{"int", "bool"}},
+					{Name: "Set", Arguments: []generator.ExportedArgument{{Name: "key", Type: "string"}, {Name: "value", Type: "int"}}},
+					{Name: "All", ReturnTypes: []string{"iter.Seq[string]"}},
+				},
+			},
+		},
+	}
+	module, err := generator.GeneratePythonModule(greeterPackage, objects)
+	if err != nil {
+		t.Fatalf("GeneratePythonModule should not return error, got %v", err)
+	}
+
+	for _, expected := range []string{
+		"    def __str__(self) -> str:\n        return self.String()\n",
+		"    def __len__(self) -> int:\n        return self.Len()\n",
+		"    def __eq__(self, other: object) -> bool:\n        if not isinstance(other, Bag):\n            return NotImplemented\n        return self.Equal(other)\n\n    __hash__ = None  # type: ignore[assignment]\n",
+		"    def __lt__(self, other: Bag) -> bool:\n",
+		"    def __getitem__(self, key: str) -> int:\n        value, ok = self.Get(key)\n        if not ok:\n            raise KeyError(key)\n        return value\n",
+		"    def __setitem__(self, key: str, value: int) -> None:\n        self.Set(key, value)\n",
+		"    def __iter__(self) -> typing.Iterator[str]:\n        return self.All()\n",
+	} {
+		if !strings.Contains(module, expected) {
+			t.Errorf("GeneratePythonModule should contain %q, got\n%s", expected, module)
+		}
+	}
+}
+
+func TestGeneratePythonModuleClosers(t *testing.T) {
+	objects := generator.ExportedObjects{
+		ExportedStructs: []generator.ExportedStruct{
+			{
+				Name: "File",
+				Methods: []generator.ExportedRoutine{
+					{Name: "Close", ReturnTypes: []string{"error"}, Doc: "Close closes the file."},
+				},
+			},
+			{
+				Name: "Stream",
+				Methods: []generator.ExportedRoutine{
+					{Name: "Wait", Directives: []string{"async"}},
+					{Name: "Close", ReturnTypes: []string{"error"}},
+				},
+			},
+		},
+	}
+	module, err := generator.GeneratePythonModule(greeterPackage, objects)
+	if err != nil {
+		t.Fatalf("GeneratePythonModule should not return error, got %v", err)
+	}
+
+	for _, expected := range []string{
+		"class File(Closable):\n",
+		"class Stream(AsyncClosable):\n",
+		"    def _close(self) -> None:\n        \"\"\"Close closes the file.\n\n        Raises:\n            GoError: If the Go call returns an error.\n        \"\"\"\n        _check(_lib.melo_mypackage_greet_File_Close(self._handle))\n",
+	} {
+		if !strings.Contains(module, expected) {
+			t.Errorf("GeneratePythonModule should contain %q, got\n%s", expected, module)
+		}
+	}
+	if strings.Contains(module, "def Close(") {
+		t.Errorf("GeneratePythonModule should leave Close to Closable, got\n%s", module)
+	}
+}
+
+func TestGeneratePythonModuleStreams(t *testing.T) {
+	module, err := generator.GeneratePythonModule(greeterPackage, streamObjects)
+	if err != nil {
+		t.Fatalf("GeneratePythonModule should not return error, got %v", err)
+	}
+
+	for _, expected := range []string{
+		"def Copy(dst: Writable, src: Readable) -> int:\n",
+		"_check(_lib.melo_mypackage_greet_Copy(_ref(dst), _ref(src), ctypes.byref(_out0)))",
+		"def Open(path: str) -> GoReader:\n",
+		"    return GoReader._from_handle(_out0.value)\n",
+	} {
+		if !strings.Contains(module, expected) {
+			t.Errorf("GeneratePythonModule should contain %q, got\n%s", expected, module)
+		}
+	}
+}
+
+func TestGeneratePythonModuleHandlers(t *testing.T) {
+	module, err := generator.GeneratePythonModule(greeterPackage, handlerObjects)
+	if err != nil {
+		t.Fatalf("GeneratePythonModule should not return error, got %v", err)
+	}
+
+	for _, expected := range []string{
+		"def App() -> Handler:\n",
+		"    return _Handler._from_handle(_out0.value)\n",
+		"class _Handler(Handler):\n    def _serve(self, *arguments) -> None:\n        _check(_lib.melo_mypackage_greet_http_serve(self._handle, *arguments))\n",
+	} {
+		if !strings.Contains(module, expected) {
+			t.Errorf("GeneratePythonModule should contain %q, got\n%s", expected, module)
+		}
+	}
+}
+
+func TestGeneratePythonModuleGenerics(t *testing.T) {
+	module, err := generator.GeneratePythonModule(greeterPackage, genericObjects)
+	if err != nil {
+		t.Fatalf("GeneratePythonModule should not return error, got %v", err)
+	}
+
+	for _, expected := range []string{
+		"def Identity_int(value: int) -> int:\n",
+		"@typing.overload\ndef Identity(value: int) -> int: ...\n",
+		"@typing.overload\ndef Identity(value: str) -> str: ...\n",
+		"def Identity(*args: typing.Any, **kwargs: typing.Any) -> typing.Any:\n" +
+			"    return _dispatch(\"Identity\", [(Identity_int, {\"value\": (int,)}), (Identity_string, {\"value\": (str,)})], args, kwargs)\n",
+		"class Box_int(GoObject):\n",
+		"class Box_ptrPerson(GoObject):\n",
+		"def NewIntBox() -> Box_int:\n",
+	} {
+		if !strings.Contains(module, expected) {
+			t.Errorf("GeneratePythonModule should contain %q, got\n%s", expected, module)
+		}
+	}
+}
+
+func TestGeneratePythonModuleEmbedding(t *testing.T) {
+	module, err := generator.GeneratePythonModule(greeterPackage, embeddingObjects)
+	if err != nil {
+		t.Fatalf("GeneratePythonModule should not return error, got %v", err)
+	}
+
+	for _, expected := range []string{
+		"class Document(GoObject):\n    _fields = (\"Base\", \"Audit\", \"Title\", \"ID\", \"Actor\",)\n\n    def __init__(self, *, Base: Base | None = None, Audit: Audit | None = None, Title: str | None = None, ID: int | None = None, Actor: str | None = None) -> None:\n",
+		"    def Describe(self) -> str:\n        _out0 = ctypes.c_void_p()\n        _check(_lib.melo_mypackage_greet_Document_Describe(self._handle, ctypes.byref(_out0)))\n",
+	} {
+		if !strings.Contains(module, expected) {
+			t.Errorf("GeneratePythonModule should contain %q, got\n%s", expected, module)
+		}
+	}
+}
+
+func TestGeneratePythonModuleTags(t *testing.T) {
+	module, err := generator.GeneratePythonModule(greeterPackage, taggedObjects)
+	if err != nil {
+		t.Fatalf("GeneratePythonModule should not return error, got %v", err)
+	}
+
+	t.Run("should name attributes after melo tags", func(t *testing.T) {
+		for _, expected := range []string{
+			"class Account(GoObject):\n    _fields = (\"UserID\", \"mail\", \"Created\", \"Password\",)\n\n    def __init__(self, *, UserID: int | None = None, mail: str | None = None, Password: str | None = None) -> None:\n",
+			"    @mail.setter\n    def mail(self, value: str) -> None:\n        _check(_lib.melo_mypackage_greet_Account_set_Email(self._handle, value.encode()))\n",
+		} {
+			if !strings.Contains(module, expected) {
+				t.Errorf("GeneratePythonModule should contain %q, got\n%s", expected, module)
+			}
+		}
+	})
+
+	t.Run("should only expose a getter for readonly fields", func(t *testing.T) {
+		if !strings.Contains(module, "    @property\n    def Created(self) -> str:\n") {
+			t.Errorf("GeneratePythonModule should expose Created, got\n%s", module)
+		}
+		if strings.Contains(module, "@Created.setter") {
+			t.Errorf("GeneratePythonModule should not expose a setter for Created, got\n%s", module)
+		}
+	})
+
+	t.Run("should leave out skipped fields", func(t *testing.T) {
+		if strings.Contains(module, "Secret") {
+			t.Errorf("GeneratePythonModule should not mention Secret, got\n%s", module)
+		}
+	})
+
+	t.Run("should use json names when the package opts in", func(t *testing.T) {
+		jsonPackage := greeterPackage
+		jsonPackage.JSONFieldNames = true
+		module, err := generator.GeneratePythonModule(jsonPackage, taggedObjects)
+		if err != nil {
+			t.Fatalf("GeneratePythonModule should not return error, got %v", err)
+		}
+
+		expected := "    _fields = (\"user_id\", \"mail\", \"Created\",)\n\n    def __init__(self, *, user_id: int | None = None, mail: str | None = None) -> None:\n"
+		if !strings.Contains(module, expected) {
+			t.Errorf("GeneratePythonModule should contain %q, got\n%s", expected, module)
+		}
+		if strings.Contains(module, "Password") {
+			t.Errorf("GeneratePythonModule should leave out fields tagged json:\"-\", got\n%s", module)
+		}
+	})
+}
+
+func TestGeneratePythonModuleVariables(t *testing.T) {
+	module, err := generator.GeneratePythonModule(greeterPackage, variableObjects)
+	if err != nil {
+		t.Fatalf("GeneratePythonModule should not return error, got %v", err)
+	}
+
+	t.Run("should expose variables as module properties", func(t *testing.T) {
+		for _, expected := range []string{
+			"class _Module(types.ModuleType):\n    @property\n    def Greeting(self) -> str:\n        _out0 = ctypes.c_void_p()\n        _check(_lib.melo_mypackage_greet_get_Greeting(ctypes.byref(_out0)))\n        return _string(_out0.value)\n",
+			"    @Greeting.setter\n    def Greeting(self, value: str) -> None:\n        _check(_lib.melo_mypackage_greet_set_Greeting(value.encode()))\n",
+			"sys.modules[__name__].__class__ = _Module\n\nGreeting: str\nVersion: int\n",
+		} {
+			if !strings.Contains(module, expected) {
+				t.Errorf("GeneratePythonModule should contain %q, got\n%s", expected, module)
+			}
+		}
+	})
+
+	t.Run("should not allow assigning readonly variables", func(t *testing.T) {
+		if strings.Contains(module, "@Version.setter") {
+			t.Errorf("GeneratePythonModule should not render a setter for Version, got\n%s", module)
+		}
+	})
+
+	t.Run("should import the modules used by the module class", func(t *testing.T) {
+		expected := "import ctypes\nimport sys\nimport types\n\n"
+		if !strings.Contains(module, expected) {
+			t.Errorf("GeneratePythonModule should contain %q, got\n%s", expected, module)
+		}
+	})
+}
+
+func TestGeneratePythonModuleImports(t *testing.T) {
+	module, err := generator.GeneratePythonModule(greeterPackage, channelObjects)
+	if err != nil {
+		t.Fatalf("GeneratePythonModule should not return error, got %v", err)
+	}
+
+	if expected := "from __future__ import annotations\n\nimport ctypes\nimport typing\n\nfrom _melo import"; !strings.Contains(module, expected) {
+		t.Errorf("GeneratePythonModule should contain %q, got\n%s", expected, module)
+	}
+}
+
+var constantObjects = generator.ExportedObjects{
+	ExportedConstants: []generator.ExportedConstant{
+		{Name: "Greeting", Type: "string", Value: "hello \"world\"\n"},
+		{Name: "Enabled", Type: "bool", Value: true},
+		{Name: "Initial", Type: "rune", Value: 'é'},
+		{Name: "Answer", Type: "int", Value: int64(42)},
+		{Name: "Huge", Type: "int", Value: new(big.Int).Lsh(big.NewInt(1), 70)},
+		{Name: "Ratio", Type: "float64", Value: 2.0},
+		{Name: "Root", Type: "complex128", Value: complex(0, 1.5)},
+		{Name: "Timeout", Type: "time.Duration", Value: int64(1500000000)},
+		{Name: "Overflow", Type: "float", Value: math.Inf(1)},
+	},
+}
+
+func TestGeneratePythonModuleConstants(t *testing.T) {
+	module, err := generator.GeneratePythonModule(greeterPackage, constantObjects)
+	if err != nil {
+		t.Fatalf("GeneratePythonModule should not return error, got %v", err)
+	}
+
+	t.Run("should emit constants as Final literals", func(t *testing.T) {
+		expected := strings.Join([]string{
+			`Greeting: typing.Final[str] = "hello \"world\"\n"`,
+			`Enabled: typing.Final[bool] = True`,
+			`Initial: typing.Final[str] = "é"`,
+			`Answer: typing.Final[int] = 42`,
+			`Huge: typing.Final[int] = 1180591620717411303424`,
+			`Ratio: typing.Final[float] = 2.0`,
+			`Root: typing.Final[complex] = complex(0.0, 1.5)`,
+			`Timeout: typing.Final[datetime.timedelta] = datetime.timedelta(microseconds=1500000)`,
+		}, "\n")
+		if !strings.Contains(module, expected) {
+			t.Errorf("GeneratePythonModule should contain %q, got\n%s", expected, module)
+		}
+	})
+
+	t.Run("should skip constants without Python literal", func(t *testing.T) {
+		if strings.Contains(module, "Overflow") {
+			t.Errorf("GeneratePythonModule should not emit Overflow, got\n%s", module)
+		}
+	})
+
+	t.Run("should not call the bridge for constants", func(t *testing.T) {
+		if strings.Contains(module, "_lib.") {
+			t.Errorf("GeneratePythonModule should not declare bridge functions, got\n%s", module)
+		}
+	})
+}
+
+func TestGenerateConstantsTest(t *testing.T) {
+	source, err := generator.GenerateConstantsTest(greeterPackage, constantObjects)
+	if err != nil {
+		t.Fatalf("GenerateConstantsTest should not return error, got %v", err)
+	}
+
+	t.Run("should render valid Go source", func(t *testing.T) {
+		if _, err := parser.ParseFile(token.NewFileSet(), "constants_test.go", source, parser.AllErrors); err != nil {
+			t.Errorf("GenerateConstantsTest should render valid Go, got %v\n%s", err, source)
+		}
+	})
+
+	t.Run("should compare every constant with its Python literal", func(t *testing.T) {
+		for _, expected := range []string{
+			"func Test_mypackage_greet_constants(t *testing.T) {\n",
+			"\tif greet.Initial != 'é' {\n",
+			"\tif greet.Huge != 1180591620717411303424 {\n",
+			"\tif float64(greet.Ratio) != 2.0 {\n",
+			"\tif complex128(greet.Root) != complex(0.0, 1.5) {\n",
+			"\tif greet.Timeout != 1500000000 {\n\t\tt.Error(\"Python constant Timeout = datetime.timedelta(microseconds=1500000) does not match the Go constant\")\n",
+		} {
+			if !strings.Contains(string(source), expected) {
+				t.Errorf("GenerateConstantsTest should contain %q, got\n%s", expected, source)
+			}
+		}
+	})
+}
+
+func TestGeneratePythonModuleDependencies(t *testing.T) {
+	module, err := generator.GeneratePythonModule(greeterPackage, aliasObjects, remoteDependencies...)
+	if err != nil {
+		t.Fatalf("GeneratePythonModule should not return error, got %v", err)
+	}
+
+	for _, expected := range []string{
+		"import mypackage.other as _mypackage_other\nimport mypackage.greet2 as _mypackage_greet2\n",
+		"def Connect(remote: _mypackage_other.Remote) -> int:\n",
+		"def Rename(user: User) -> None:\n",
+		"Person = User\nRemoteAlias = _mypackage_other.Remote\n",
+	} {
+		if !strings.Contains(module, expected) {
+			t.Errorf("GeneratePythonModule should contain %q, got\n%s", expected, module)
+		}
+	}
+	if strings.Contains(module, "Lookup") {
+		t.Errorf("GeneratePythonModule should not render Lookup, got\n%s", module)
+	}
+}
+
+func TestGeneratePythonModuleAnonymousTypes(t *testing.T) {
+	module, err := generator.GeneratePythonModule(greeterPackage, anonymousObjects)
+	if err != nil {
+		t.Fatalf("GeneratePythonModule should not return error, got %v", err)
+	}
+
+	t.Run("should name anonymous structs after where they appear", func(t *testing.T) {
+		for _, expected := range []string{
+			"class Configure_options_Struct(typing.TypedDict):\n    Timeout: int\n    Name: str\n",
+			"def Configure(options: Configure_options_Struct) -> Configure_Result_Struct:",
+			"_lib.melo_mypackage_greet_Configure(_bounded(options[\"Timeout\"], -9223372036854775808, 9223372036854775807), options[\"Name\"].encode(), ctypes.byref(_out0F0))",
+			"return {\"Applied\": _out0F0.value}",
+		} {
+			if !strings.Contains(module, expected) {
+				t.Errorf("GeneratePythonModule should contain %q, got\n%s", expected, module)
+			}
+		}
+	})
+
+	t.Run("should accept callables for func arguments", func(t *testing.T) {
+		for _, expected := range []string{
+			"def Apply(value: int, transform: typing.Callable[[int], str]) -> str:",
+			"_check(_lib.melo_mypackage_greet_Apply(_bounded(value, -9223372036854775808, 9223372036854775807), _ref(transform), ctypes.byref(_out0)))",
+			"result = _deref(ref)(in0)",
+			"_lib.melo_mypackage_greet_func_int_to_string_register(_callback_int_to_string)",
+		} {
+			if !strings.Contains(module, expected) {
+				t.Errorf("GeneratePythonModule should contain %q, got\n%s", expected, module)
+			}
+		}
+	})
+
+	t.Run("should wrap returned functions in callables", func(t *testing.T) {
+		for _, expected := range []string{
+			"def Deferred() -> typing.Callable[[str], int]:",
+			"return _Func_string_to_int_error._from_handle(_out0.value)",
+			"class _Func_string_to_int_error(GoObject):\n    def __call__(self, arg0: str) -> int:",
+		} {
+			if !strings.Contains(module, expected) {
+				t.Errorf("GeneratePythonModule should contain %q, got\n%s", expected, module)
+			}
+		}
+	})
+}
+
+func TestGeneratePythonModuleNumbers(t *testing.T) {
+	module, err := generator.GeneratePythonModule(greeterPackage, numberObjects)
+	if err != nil {
+		t.Fatalf("GeneratePythonModule should not return error, got %v", err)
+	}
+
+	t.Run("should map complex and arbitrary precision numbers", func(t *testing.T) {
+		for _, expected := range []string{
+			"def Rotate(point: complex) -> complex:",
+			"_check(_lib.melo_mypackage_greet_Rotate(_complex_text(point), ctypes.byref(_out0)))",
+			"return _number(_out0.value, _parse_complex)",
+			"def Factorial(n: int) -> int:",
+			"return _number(_out0.value, int)",
+			"def Scale(value: decimal.Decimal, ratio: fractions.Fraction) -> decimal.Decimal:",
+			"_check(_lib.melo_mypackage_greet_Scale(_decimal_text(value), _fraction_text(ratio), ctypes.byref(_out0)))",
+		} {
+			if !strings.Contains(module, expected) {
+				t.Errorf("GeneratePythonModule should contain %q, got\n%s", expected, module)
+			}
+		}
+	})
+
+	t.Run("should check the range of integers", func(t *testing.T) {
+		for _, expected := range []string{
+			"_lib.melo_mypackage_greet_Factorial(_bounded(n, 0, 65535), ctypes.byref(_out0))",
+			"_lib.melo_mypackage_greet_Raise(_bounded(level, -128, 127))",
+		} {
+			if !strings.Contains(module, expected) {
+				t.Errorf("GeneratePythonModule should contain %q, got\n%s", expected, module)
+			}
+		}
+	})
+}
+
+var deprecatedObjects = generator.ExportedObjects{
+	ExportedVariables: []generator.ExportedVariable{
+		{Name: "Timeout", Type: "int", Doc: "Timeout is the default timeout.\n\nDeprecated: Use\nDefaultTimeout instead."},
+	},
+	ExportedStructs: []generator.ExportedStruct{
+		{
+			Name:   "Client",
+			Doc:    "Client calls the service.\n\nDeprecated: Use Session instead.",
+			Fields: []generator.ExportedField{{Name: "Host", Type: "string", Doc: "Deprecated: Use Address."}},
+			Methods: []generator.ExportedRoutine{
+				{Name: "Ping", ReturnTypes: []string{"error"}, Doc: "Ping checks the service.\n\nDeprecated: Use Check."},
+			},
+		},
+	},
+	ExportedFunctions: []generator.ExportedRoutine{
+		{Name: "Dial", Arguments: []generator.ExportedArgument{{Name: "host", Type: "string"}}, ReturnTypes: []string{"*example.com/greet.Client"}, Doc: "Dial connects.\n\nDeprecated: Use Open."},
+		{Name: "Open", Doc: "Open is not deprecated, despite mentioning Deprecated: in a sentence."},
+	},
+}
+
+func TestGeneratePythonModuleDeprecations(t *testing.T) {
+	module, err := generator.GeneratePythonModule(greeterPackage, deprecatedObjects)
+	if err != nil {
+		t.Fatalf("GeneratePythonModule should not return error, got %v", err)
+	}
+
+	t.Run("should import the deprecated decorator for type checkers", func(t *testing.T) {
+		for _, expected := range []string{
+			"import typing\nimport warnings\n",
+			"if typing.TYPE_CHECKING:\n    if sys.version_info >= (3, 13):\n        from warnings import deprecated as _deprecated\n    else:\n        from typing_extensions import deprecated as _deprecated\nelse:\n    from _melo import deprecated as _deprecated\n",
+		} {
+			if !strings.Contains(module, expected) {
+				t.Errorf("GeneratePythonModule should contain %q, got\n%s", expected, module)
+			}
+		}
+	})
+
+	t.Run("should decorate and warn on deprecated declarations", func(t *testing.T) {
+		for _, expected := range []string{
+			"@_deprecated(\"Use Open.\")\ndef Dial(host: str) -> Client:",
+			"warnings.warn(\"Dial is deprecated: Use Open.\", DeprecationWarning, stacklevel=2)",
+			"@_deprecated(\"Use Session instead.\")\nclass Client(GoObject):",
+			"    @_deprecated(\"Use Session instead.\")\n    def __init__(self, *, Host: str | None = None) -> None:\n        warnings.warn(\"Client is deprecated: Use Session instead.\", DeprecationWarning, stacklevel=2)",
+			"    @property\n    @_deprecated(\"Use Address.\")\n    def Host(self) -> str:",
+			"    @_deprecated(\"Use Check.\")\n    def Ping(self) -> None:",
+			"    @property\n    @_deprecated(\"Use DefaultTimeout instead.\")\n    def Timeout(self) -> int:",
+			"warnings.warn(\"Timeout is deprecated: Use DefaultTimeout instead.\", DeprecationWarning, stacklevel=2)",
+		} {
+			if !strings.Contains(module, expected) {
+				t.Errorf("GeneratePythonModule should contain %q, got\n%s", expected, module)
+			}
+		}
+	})
+
+	t.Run("should only deprecate on a Deprecated: paragraph", func(t *testing.T) {
+		if strings.Contains(module, "Open is deprecated") {
+			t.Errorf("GeneratePythonModule should not deprecate Open, got\n%s", module)
+		}
+	})
+}
+
+func TestGeneratePythonModuleWithoutDeprecations(t *testing.T) {
+	module, err := generator.GeneratePythonModule(greeterPackage, greeterObjects)
+	if err != nil {
+		t.Fatalf("GeneratePythonModule should not return error, got %v", err)
+	}
+	for _, unexpected := range []string{"import warnings", "_deprecated"} {
+		if strings.Contains(module, unexpected) {
+			t.Errorf("GeneratePythonModule should not contain %q, got\n%s", unexpected, module)
+		}
+	}
+}
+
+var documentedObjects = generator.ExportedObjects{
+	ExportedConstants:  []generator.ExportedConstant{{Name: "Scale", Type: "int", Value: int64(2)}},
+	ExportedInterfaces: []generator.ExportedInterface{{Name: "Shape", Doc: "Shape is drawn by [Render]."}},
+	ExportedStructs: []generator.ExportedStruct{
+		{
+			Name:    "Canvas",
+			Doc:     "Canvas is drawn on as \"the page\"",
+			Fields:  []generator.ExportedField{{Name: "Width", Type: "int", Doc: "Width of the canvas, in pixels."}},
+			Methods: []generator.ExportedRoutine{{Name: "Draw", Arguments: []generator.ExportedArgument{{Name: "shape", Type: "example.com/greet.Shape"}}}},
+		},
+	},
+	ExportedFunctions: []generator.ExportedRoutine{
+		{Name: "NewCanvas", ReturnTypes: []string{"*example.com/greet.Canvas"}},
+		{Name: "NewCanvasFromFile", Arguments: []generator.ExportedArgument{{Name: "path", Type: "string"}}, ReturnTypes: []string{"*example.com/greet.Canvas", "error"}},
+		{
+			Name:        "Render",
+			Arguments:   []generator.ExportedArgument{{Name: "shape", Type: "example.com/greet.Shape"}, {Name: "canvas", Type: "*example.com/greet.Canvas"}, {Name: "scales", Type: "[]int", Variadic: true}},
+			ReturnTypes: []string{"int", "error"},
+			Doc: "Render draws a [Shape] on a [Canvas], built by [NewCanvas] or [NewCanvasFromFile],\n" +
+				"like [Canvas.Draw] does. See [Scale], [io.Reader] and [other.Remote].\n\n" +
+				"# Options\n\n" +
+				"The options are:\n  - fast: skips antialiasing\n  - slow\n\n" +
+				"For example:\n\n\tRender(shape, canvas)\n\n" +
+				"Read the [spec].\n\n[spec]: https://example.com/spec\n",
+		},
+	},
+}
+
+func TestGeneratePythonModuleDocstrings(t *testing.T) {
+	module, err := generator.GeneratePythonModule(greeterPackage, documentedObjects, remoteDependencies...)
+	if err != nil {
+		t.Fatalf("GeneratePythonModule should not return error, got %v", err)
+	}
+
+	t.Run("should rewrite doc links to Python names", func(t *testing.T) {
+		for _, expected := range []string{
+			"\"\"\"Render draws a :class:`Shape` on a :class:`Canvas`, built by\n    :class:`Canvas` or :meth:`Canvas.from_file`, like :meth:`Canvas.Draw`\n",
+			"See :data:`Scale`, ``io.Reader`` and\n    :class:`mypackage.other.Remote`.",
+			"\"\"\"Shape is drawn by :func:`Render`.\"\"\"",
+		} {
+			if !strings.Contains(module, expected) {
+				t.Errorf("GeneratePythonModule should contain %q, got\n%s", expected, module)
+			}
+		}
+	})
+
+	t.Run("should render headings, lists, code blocks and links", func(t *testing.T) {
+		for _, expected := range []string{
+			"    Options\n    -------\n\n    The options are:\n\n    - fast: skips antialiasing\n    - slow\n",
+			"    .. code-block:: go\n\n        Render(shape, canvas)\n",
+			"    Read the `spec <https://example.com/spec>`_.\n",
+		} {
+			if !strings.Contains(module, expected) {
+				t.Errorf("GeneratePythonModule should contain %q, got\n%s", expected, module)
+			}
+		}
+	})
+
+	t.Run("should document the signature", func(t *testing.T) {
+		expected := "    Args:\n        shape (Shape)\n        canvas (Canvas)\n        *scales (int)\n\n    Returns:\n        int\n\n    Raises:\n        GoError: If the Go call returns an error.\n    \"\"\"\n"
+		if !strings.Contains(module, expected) {
+			t.Errorf("GeneratePythonModule should contain %q, got\n%s", expected, module)
+		}
+	})
+
+	t.Run("should document struct fields", func(t *testing.T) {
+		expected := "    @property\n    def Width(self) -> int:\n        \"\"\"Width of the canvas, in pixels.\n"
+		if !strings.Contains(module, expected) {
+			t.Errorf("GeneratePythonModule should contain %q, got\n%s", expected, module)
+		}
+	})
+
+	t.Run("should escape a quote ending the doc", func(t *testing.T) {
+		expected := "    \"\"\"Canvas is drawn on as \"the page\\\"\"\"\"\n"
+		if !strings.Contains(module, expected) {
+			t.Errorf("GeneratePythonModule should contain %q, got\n%s", expected, module)
+		}
+	})
+
+	t.Run("should not document undocumented routines", func(t *testing.T) {
+		expected := "    def Draw(self, shape: Shape) -> None:\n        _check("
+		if !strings.Contains(module, expected) {
+			t.Errorf("GeneratePythonModule should contain %q, got\n%s", expected, module)
+		}
+	})
+}
+
+var exampleObjects = generator.ExportedObjects{
+	ExportedFunctions: []generator.ExportedRoutine{
+		{Name: "Add", Doc: "Add sums two numbers.", Arguments: []generator.ExportedArgument{{Name: "a", Type: "int"}, {Name: "b", Type: "int"}}, ReturnTypes: []string{"int"}},
+		{Name: "Greet", Arguments: []generator.ExportedArgument{{Name: "names", Type: "[]string", Variadic: true}}, ReturnTypes: []string{"string"}},
+		{Name: "Ready", ReturnTypes: []string{"bool"}},
+	},
+	ExportedExamples: []generator.ExportedExample{
+		{
+			Name:      "Add",
+			Calls:     []generator.ExampleCall{{Function: "Add", Arguments: []string{"1", "'a'"}, Printed: true}, {Function: "Add", Arguments: []string{"-1", "1"}}},
+			Output:    "98\n",
+			HasOutput: true,
+		},
+		{
+			Name:      "Greet",
+			Calls:     []generator.ExampleCall{{Function: "Greet", Arguments: []string{`"Ana"`, "`Bob`"}, Printed: true}},
+			Output:    "Hello, Ana and Bob\n",
+			HasOutput: true,
+		},
+		{
+			Name:      "Ready",
+			Calls:     []generator.ExampleCall{{Function: "Ready", Printed: true}},
+			Output:    "true\n",
+			HasOutput: true,
+		},
+		{Name: "Add", Suffix: "second", Problem: "line 3: only calls are translated"},
+	},
+}
+
+func TestGeneratePythonModuleDoctests(t *testing.T) {
+	doctestPackage := greeterPackage
+	doctestPackage.Doctests = true
+	module, err := generator.GeneratePythonModule(doctestPackage, exampleObjects)
+	if err != nil {
+		t.Fatalf("GeneratePythonModule should not return error, got %v", err)
+	}
+
+	t.Run("should translate examples into doctests", func(t *testing.T) {
+		for _, expected := range []string{
+			"    Returns:\n        int\n\n    Examples:\n        >>> Add(1, 97)\n        98\n        >>> _ = Add(-1, 1)\n    \"\"\"\n",
+			"    \"\"\"Examples:\n        >>> print(Greet(\"Ana\", \"Bob\"))\n        Hello, Ana and Bob\n    \"\"\"\n",
+		} {
+			if !strings.Contains(module, expected) {
+				t.Errorf("GeneratePythonModule should contain %q, got\n%s", expected, module)
+			}
+		}
+	})
+
+	t.Run("should skip examples printing values Python prints differently", func(t *testing.T) {
+		if strings.Contains(module, ">>> Ready()") {
+			t.Errorf("GeneratePythonModule should not translate ExampleReady, got\n%s", module)
+		}
+	})
+
+	t.Run("should document the doctests", func(t *testing.T) {
+		for format, expected := range map[string]string{
+			generator.MarkdownFormat: "Examples:\n\n```pycon\n>>> Add(1, 97)\n98\n>>> _ = Add(-1, 1)\n```\n",
+			generator.RestFormat:     "   Examples:\n       >>> Add(1, 97)\n       98\n",
+		} {
+			docs, err := generator.GenerateDocs(doctestPackage, exampleObjects, format)
+			if err != nil {
+				t.Fatalf("GenerateDocs should not return error, got %v", err)
+			}
+			if !strings.Contains(docs, expected) {
+				t.Errorf("GenerateDocs should contain %q, got\n%s", expected, docs)
+			}
+		}
+	})
+
+	t.Run("should not translate examples unless enabled", func(t *testing.T) {
+		module, err := generator.GeneratePythonModule(greeterPackage, exampleObjects)
+		if err != nil {
+			t.Fatalf("GeneratePythonModule should not return error, got %v", err)
+		}
+		if strings.Contains(module, "Examples:") {
+			t.Errorf("GeneratePythonModule should not contain examples, got\n%s", module)
+		}
+	})
+}
+
+func TestGenerateDocs(t *testing.T) {
+	objects := documentedObjects
+	objects.GoDeclarations = map[string]generator.GoDeclaration{
+		"Render":      {Source: "func Render(shape Shape, canvas *Canvas, scales ...int) (int, error)", Position: "render.go:12"},
+		"Canvas":      {Source: "type Canvas struct {\n\tWidth int\n}", Position: "canvas.go:3"},
+		"Canvas.Draw": {Source: "func (canvas *Canvas) Draw(shape Shape)", Position: "canvas.go:9"},
+	}
+
+	t.Run("should render Markdown pages", func(t *testing.T) {
+		docs, err := generator.GenerateDocs(greeterPackage, objects, generator.MarkdownFormat, remoteDependencies...)
+		if err != nil {
+			t.Fatalf("GenerateDocs should not return error, got %v", err)
+		}
+		for _, expected := range []string{
+			"# `mypackage.greet`\n\nPython module generated from the Go package `example.com/greet`.\n",
+			"<a id=\"Render\"></a>\n\n### `Render`\n\n```python\ndef Render(shape: Shape, canvas: Canvas, *scales: int) -> int\n```\n\n```go\n// render.go:12\nfunc Render(shape Shape, canvas *Canvas, scales ...int) (int, error)\n```\n",
+			"Render draws a [Shape](#Shape) on a [Canvas](#Canvas), built by [Canvas](#Canvas) or [Canvas.from\\_file](#Canvas.from_file)",
+			"[mypackage.other.Remote](mypackage.other.md#Remote)",
+			"[io.Reader](https://pkg.go.dev/io#Reader)",
+			"#### Options\n",
+			"```python\nclass Canvas()\n```\n\n```go\n// canvas.go:3\ntype Canvas struct {\n\tWidth int\n}\n```\n",
+			"#### `Canvas.from_file`\n\n```python\n@classmethod\ndef from_file(cls, path: str) -> typing.Self\n```\n",
+			"#### `Canvas.Draw`\n\n```python\ndef Draw(self, shape: Shape) -> None\n```\n\n```go\n// canvas.go:9\n",
+			"#### `Canvas.Width`\n\n```python\nWidth: int\n```\n\nWidth of the canvas, in pixels.\n",
+			"## Protocols\n\n<a id=\"Shape\"></a>\n\n### `Shape`\n\n```python\nclass Shape(typing.Protocol)\n```\n",
+			"```python\nScale: typing.Final[int] = 2\n```\n",
+		} {
+			if !strings.Contains(docs, expected) {
+				t.Errorf("GenerateDocs should contain %q, got\n%s", expected, docs)
+			}
+		}
+	})
+
+	t.Run("should render Sphinx directives", func(t *testing.T) {
+		docs, err := generator.GenerateDocs(greeterPackage, objects, generator.RestFormat, remoteDependencies...)
+		if err != nil {
+			t.Fatalf("GenerateDocs should not return error, got %v", err)
+		}
+		for _, expected := range []string{
+			"mypackage.greet\n===============\n\n.. py:module:: mypackage.greet\n",
+			".. py:data:: Scale\n   :type: typing.Final[int]\n   :value: 2\n",
+			".. py:function:: Render(shape: Shape, canvas: Canvas, *scales: int) -> int\n\n   Render draws a :class:`Shape` on a :class:`Canvas`, built by\n",
+			"   Raises:\n       GoError: If the Go call returns an error.\n\n   .. code-block:: go\n\n       // render.go:12\n",
+			"   .. py:classmethod:: from_file(path: str) -> typing.Self\n",
+			"   .. py:property:: Width\n      :type: int\n\n      Width of the canvas, in pixels.\n",
+			"   .. py:method:: Draw(shape: Shape) -> None\n",
+			".. py:class:: Shape()\n\n   Bases: :class:`typing.Protocol`\n",
+		} {
+			if !strings.Contains(docs, expected) {
+				t.Errorf("GenerateDocs should contain %q, got\n%s", expected, docs)
+			}
+		}
+	})
+
+	t.Run("should reject unknown formats", func(t *testing.T) {
+		if _, err := generator.GenerateDocs(greeterPackage, objects, "html"); err == nil {
+			t.Errorf("GenerateDocs should return an error for unknown formats")
+		}
+	})
+}
+
+var directiveObjects = generator.ExportedObjects{
+	ExportedStructs: []generator.ExportedStruct{
+		{
+			Name: "Client",
+			Fields: []generator.ExportedField{
+				{Name: "BaseURL", Type: "string"},
+				{Name: "Token", Type: "string", Directives: []string{"name api_token", "readonly"}},
+			},
+			Methods: []generator.ExportedRoutine{
+				{Name: "FetchPage", Arguments: []generator.ExportedArgument{{Name: "path", Type: "string"}}, ReturnTypes: []string{"string", "error"}, Directives: []string{"async"}},
+				{Name: "String", ReturnTypes: []string{"string"}},
+				{Name: "Reset"},
+			},
+		},
+	},
+	ExportedFunctions: []generator.ExportedRoutine{
+		{Name: "SayHello", ReturnTypes: []string{"string"}, Directives: []string{"name hello"}},
+		{Name: "MaxRetries", ReturnTypes: []string{"int"}},
+	},
+}
+
+func TestGeneratePythonModuleDirectives(t *testing.T) {
+	t.Run("should follow the name, readonly and async directives", func(t *testing.T) {
+		module, err := generator.GeneratePythonModule(greeterPackage, directiveObjects)
+		if err != nil {
+			t.Fatalf("GeneratePythonModule should not return error, got %v", err)
+		}
+
+		for _, expected := range []string{
+			"import asyncio\n",
+			"def hello() -> str:\n",
+			"    @property\n    def api_token(self) -> str:\n",
+			"    async def FetchPage(self, path: str) -> str:\n",
+			"_check(await asyncio.to_thread(_lib.melo_mypackage_greet_Client_FetchPage, self._handle, ",
+		} {
+			if !strings.Contains(module, expected) {
+				t.Errorf("GeneratePythonModule should contain %q, got\n%s", expected, module)
+			}
+		}
+		if strings.Contains(module, "@api_token.setter") || strings.Contains(module, "def SayHello(") {
+			t.Errorf("GeneratePythonModule should rename SayHello and keep api_token read-only, got\n%s", module)
+		}
+	})
+
+	t.Run("should spell names in snake case with the snake name style", func(t *testing.T) {
+		snakePackage := greeterPackage
+		snakePackage.NameStyle = files.SnakeNameStyle
+		module, err := generator.GeneratePythonModule(snakePackage, directiveObjects)
+		if err != nil {
+			t.Fatalf("GeneratePythonModule should not return error, got %v", err)
+		}
+
+		for _, expected := range []string{
+			"def hello() -> str:\n",
+			"def max_retries() -> int:\n",
+			"    _fields = (\"base_url\", \"api_token\",)\n",
+			"    async def fetch_page(self, path: str) -> str:\n",
+			"    def reset(self) -> None:\n",
+			"    def __str__(self) -> str:\n        return self.string()\n",
+		} {
+			if !strings.Contains(module, expected) {
+				t.Errorf("GeneratePythonModule should contain %q, got\n%s", expected, module)
+			}
+		}
+	})
+
+	t.Run("should leave out the declarations excluded by the package", func(t *testing.T) {
+		excludingPackage := greeterPackage
+		excludingPackage.Exclude = []string{"MaxRetries", "Client.Reset", "Client.BaseURL"}
+		module, err := generator.GeneratePythonModule(excludingPackage, directiveObjects)
+		if err != nil {
+			t.Fatalf("GeneratePythonModule should not return error, got %v", err)
+		}
+
+		for _, excluded := range []string{"MaxRetries", "Reset", "BaseURL"} {
+			if strings.Contains(module, excluded) {
+				t.Errorf("GeneratePythonModule should not mention %s, got\n%s", excluded, module)
+			}
+		}
+		if !strings.Contains(module, "def hello() -> str:\n") {
+			t.Errorf("GeneratePythonModule should keep hello, got\n%s", module)
+		}
+	})
+
+	t.Run("should report excluded names matching no declaration once", func(t *testing.T) {
+		excludingPackage := greeterPackage
+		excludingPackage.Exclude = []string{"Missing"}
+
+		output := &bytes.Buffer{}
+		log.SetOutput(output)
+		defer log.SetOutput(os.Stderr)
+		if _, err := generator.GenerateBridge(excludingPackage, directiveObjects); err != nil {
+			t.Fatalf("GenerateBridge should not return error, got %v", err)
+		}
+		if _, err := generator.GeneratePythonModule(excludingPackage, directiveObjects); err != nil {
+			t.Fatalf("GeneratePythonModule should not return error, got %v", err)
+		}
+
+		if count := strings.Count(output.String(), "Cannot exclude Missing from example.com/greet"); count != 1 {
+			t.Errorf("Missing should be reported once, got %d times in\n%s", count, output.String())
+		}
+	})
+}
@@ -0,0 +1,581 @@
+//go:build roundtrip
+
+package generator_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/EdmilsonRodrigues/melo-project/src/melo/files"
+	"github.com/EdmilsonRodrigues/melo-project/src/melo/generator"
+)
+
+// The round trip tests build the generated bridge into a shared library with
+// cgo and drive it from Python, so they need a C compiler and python3:
+//
+//	go test -tags roundtrip ./generator/
+
+const roundTripModule = "module example.com/roundtrip\n\ngo 1.24\n"
+
+const roundTripLibrary = `package lib
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"iter"
+	"math/big"
+	"net/http"
+	"runtime"
+	"strings"
+	"sync/atomic"
+)
+
+var stopped atomic.Int64
+
+// Count counts from zero to n, until the context is cancelled.
+func Count(ctx context.Context, n int) <-chan int {
+	values := make(chan int)
+	go func() {
+		defer close(values)
+		defer stopped.Add(1)
+		for index := 0; index < n; index++ {
+			select {
+			case values <- index:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return values
+}
+
+// Stopped is the number of counts that returned.
+func Stopped() int {
+	return int(stopped.Load())
+}
+
+// Letters streams n letters, without a context to stop it early.
+func Letters(n int) <-chan string {
+	values := make(chan string)
+	go func() {
+		defer close(values)
+		for index := 0; index < n; index++ {
+			values <- string(rune('a' + index%26))
+		}
+	}()
+	return values
+}
+
+// Goroutines is the number of running goroutines.
+func Goroutines() int {
+	return runtime.NumGoroutine()
+}
+
+var sequencesStopped atomic.Int64
+
+// Numbers yields the numbers from zero to n.
+func Numbers(n int) iter.Seq[int] {
+	return func(yield func(int) bool) {
+		defer sequencesStopped.Add(1)
+		for index := 0; index < n; index++ {
+			if !yield(index) {
+				return
+			}
+		}
+	}
+}
+
+// Squares yields the numbers from zero to n along with their square.
+func Squares(n int) iter.Seq2[int, int] {
+	return func(yield func(int, int) bool) {
+		defer sequencesStopped.Add(1)
+		for index := 0; index < n; index++ {
+			if !yield(index, index*index) {
+				return
+			}
+		}
+	}
+}
+
+// SequencesStopped is the number of sequences that returned.
+func SequencesStopped() int {
+	return int(sequencesStopped.Load())
+}
+
+// Join joins names, each followed by a comma.
+func Join(names <-chan string) string {
+	joined := ""
+	for name := range names {
+		joined += name + ","
+	}
+	return joined
+}
+
+// Upper streams names in upper case.
+func Upper(names <-chan string) <-chan string {
+	values := make(chan string)
+	go func() {
+		defer close(values)
+		for name := range names {
+			values <- strings.ToUpper(name)
+		}
+	}()
+	return values
+}
+
+// Capacity is the capacity of a send-only channel.
+func Capacity(values chan<- string) int {
+	return cap(values)
+}
+
+// Divide divides a by b, and panics when b is zero.
+func Divide(a, b int) int {
+	return a / b
+}
+
+// Pair is written as "left, right"
+type Pair struct {
+	Left  int
+	Right int
+}
+
+// Equal reports whether both pairs hold the same numbers.
+func (pair *Pair) Equal(other *Pair) bool {
+	return *pair == *other
+}
+
+// Greeter greets people.
+type Greeter interface {
+	Greet(name string) string
+}
+
+// GreetLater has the greeter greet a name from another goroutine.
+func GreetLater(greeter Greeter, name string) string {
+	greeting := make(chan string)
+	go func() {
+		greeting <- greeter.Greet(name)
+	}()
+	return <-greeting
+}
+
+var closed atomic.Int64
+
+// File is released by Close.
+type File struct {
+	name string
+}
+
+// NewFile opens a file.
+func NewFile(name string) *File {
+	return &File{name: name}
+}
+
+// Name is the name of the file.
+func (file *File) Name() string {
+	return file.name
+}
+
+// Close closes the file.
+func (file *File) Close() error {
+	closed.Add(1)
+	return nil
+}
+
+// Stream is a File used from coroutines.
+type Stream struct{}
+
+// Wait waits for the stream.
+//
+// melo:async
+func (stream *Stream) Wait() string {
+	return "ready"
+}
+
+// Close closes the stream.
+func (stream *Stream) Close() error {
+	closed.Add(1)
+	return nil
+}
+
+// Closed is the number of files and streams closed.
+func Closed() int {
+	return int(closed.Load())
+}
+
+// Shout copies src to dst in upper case, returning the number of bytes
+// written.
+func Shout(dst io.Writer, src io.Reader) (int64, error) {
+	data, err := io.ReadAll(src)
+	if err != nil {
+		return 0, err
+	}
+	written, err := dst.Write(bytes.ToUpper(data))
+	return int64(written), err
+}
+
+// Text reads a text.
+func Text(text string) io.ReadCloser {
+	return io.NopCloser(strings.NewReader(text))
+}
+
+// App greets, echoes request bodies and answers with a status code unknown
+// to Python.
+//
+// melo:asgi
+func App() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /hello/{name}", func(response http.ResponseWriter, request *http.Request) {
+		response.Header().Set("X-Greeting", "hello")
+		fmt.Fprintf(response, "hello %s from %s", request.PathValue("name"), request.URL.Query().Get("from"))
+	})
+	mux.HandleFunc("POST /echo", func(response http.ResponseWriter, request *http.Request) {
+		body, _ := io.ReadAll(request.Body)
+		response.Header().Set("Content-Type", request.Header.Get("Content-Type"))
+		response.WriteHeader(http.StatusCreated)
+		response.Write(body)
+	})
+	mux.HandleFunc("/odd", func(response http.ResponseWriter, request *http.Request) {
+		response.WriteHeader(299)
+	})
+	return mux
+}
+
+// Greeting is the greeting of Hello.
+var Greeting = "hello"
+
+// Version is the version of the library.
+//
+// melo:readonly
+var Version = 1
+
+// Hello greets a name with the greeting.
+func Hello(name string) string {
+	return Greeting + " " + name
+}
+
+// Half halves a small integer.
+func Half(value int8) int8 {
+	return value / 2
+}
+
+// Double doubles a big integer.
+func Double(value *big.Int) *big.Int {
+	return new(big.Int).Lsh(value, 1)
+}
+
+// Invert inverts a fraction.
+func Invert(value *big.Rat) *big.Rat {
+	return new(big.Rat).Inv(value)
+}
+
+// Conjugate conjugates a complex number.
+func Conjugate(value complex128) complex128 {
+	return complex(real(value), -imag(value))
+}
+
+// Named has a name.
+type Named struct {
+	Name string
+}
+
+// Entry embeds a pointer to its name.
+type Entry struct {
+	*Named
+}
+`
+
+const roundTripScript = `
+import asyncio
+import fractions
+import io
+import time
+
+import lib
+
+assert list(lib.Count(3)) == [0, 1, 2]
+
+channel = lib.Count(1000)
+for value in channel:
+    if value == 2:
+        break
+channel.close()
+deadline = time.monotonic() + 5
+while lib.Stopped() < 2 and time.monotonic() < deadline:
+    time.sleep(0.01)
+assert lib.Stopped() == 2, "closing the channel should stop the producer"
+
+
+
+def settle(goroutines):
+    deadline = time.monotonic() + 5
+    while lib.Goroutines() != goroutines and time.monotonic() < deadline:
+        time.sleep(0.01)
+    return lib.Goroutines()
+
+
+goroutines = settle(lib.Goroutines())
+for produce in (lambda: lib.Count(1000), lambda: lib.Letters(1000), lambda: lib.Upper("a" for _ in range(1000))):
+    channel = produce()
+    for value in channel:
+        break
+    channel.close()
+    assert settle(goroutines) == goroutines, "breaking early should stop the goroutines of the producer"
+
+assert list(lib.Numbers(3)) == [0, 1, 2]
+assert list(lib.Squares(3)) == [(0, 0), (1, 1), (2, 4)]
+assert lib.SequencesStopped() == 2
+for produce in (lambda: lib.Numbers(1000), lambda: lib.Squares(1000)):
+    for value in produce():
+        break
+    assert settle(goroutines) == goroutines, "breaking early should stop the goroutine pulling the sequence"
+assert lib.SequencesStopped() == 4, "breaking early should stop the sequences"
+
+assert lib.Join(["a", "b"]) == "a,b,"
+assert lib.Join(iter(())) == ""
+assert list(lib.Upper(name for name in ["a", "b"])) == ["A", "B"]
+assert lib.Capacity(["a"]) == 0
+
+
+def failing():
+    yield "a"
+    raise ValueError("boom")
+
+
+for call in (lambda: lib.Join(failing()), lambda: list(lib.Upper(failing()))):
+    try:
+        call()
+    except lib.GoError as error:
+        assert "boom" in str(error), error
+    else:
+        raise AssertionError("the exception of the iterator should fail the call")
+
+try:
+    lib.Divide(1, 0)
+except lib.GoError as error:
+    assert "divide by zero" in str(error), error
+else:
+    raise AssertionError("the panic should fail the call")
+
+assert lib.Pair.__doc__ == 'Pair is written as "left, right"', lib.Pair.__doc__
+assert lib.Divide.__doc__.startswith("Divide divides a by b, and panics when b is zero."), lib.Divide.__doc__
+assert lib.Pair() == lib.Pair()
+try:
+    hash(lib.Pair())
+except TypeError:
+    pass
+else:
+    raise AssertionError("pairs compared with Equal should be unhashable")
+
+
+class Polite:
+    def Greet(self, name):
+        return "hello " + name
+
+
+class Failing:
+    def Greet(self, name):
+        raise ValueError("no greeting")
+
+
+assert lib.GreetLater(Polite(), "melo") == "hello melo"
+assert lib.GreetLater(Failing(), "melo") == "", "the exception should give zero values"
+
+with lib.File("notes") as file:
+    assert file.Name() == "notes"
+assert lib.Closed() == 1
+try:
+    file.Name()
+except lib.ClosedError as error:
+    assert "File is closed" in str(error), error
+else:
+    raise AssertionError("using a closed file should fail")
+file.Close()
+assert lib.Closed() == 1, "closing twice should do nothing"
+assert not hasattr(lib.File, "__aenter__"), "only values used from coroutines should be async context managers"
+
+
+async def wait():
+    async with lib.Stream() as stream:
+        return await stream.Wait()
+
+
+assert asyncio.run(wait()) == "ready"
+assert lib.Closed() == 2
+
+source, target = io.BytesIO(b"melo"), io.BytesIO()
+assert lib.Shout(target, source) == 4
+assert target.getvalue() == b"MELO"
+
+
+class Broken:
+    def read(self, size):
+        raise OSError("disk failure")
+
+
+try:
+    lib.Shout(io.BytesIO(), Broken())
+except lib.GoError as error:
+    assert "disk failure" in str(error), error
+else:
+    raise AssertionError("the exception of the reader should fail the call")
+with io.BufferedReader(lib.Text("one\ntwo\n")) as reader:
+    assert reader.readlines() == [b"one\n", b"two\n"]
+assert reader.closed
+
+
+
+async def request(app, method, path, query=b"", body=b"", headers=()):
+    scope = {
+        "type": "http",
+        "method": method,
+        "path": path,
+        "root_path": "",
+        "query_string": query,
+        "headers": [(b"host", b"example.test"), *headers],
+        "client": ("127.0.0.1", 5000),
+    }
+    messages = [{"type": "http.request", "body": body[:2], "more_body": True}, {"type": "http.request", "body": body[2:]}]
+    sent = []
+
+    async def receive():
+        return messages.pop(0)
+
+    async def send(message):
+        sent.append(message)
+
+    await app(scope, receive, send)
+    return sent
+
+
+app = lib.App()
+start, body = asyncio.run(request(app, "GET", "/hello/melo", query=b"from=python"))
+assert start["type"] == "http.response.start" and start["status"] == 200, start
+assert (b"x-greeting", b"hello") in start["headers"], start
+assert body == {"type": "http.response.body", "body": b"hello melo from python"}, body
+start, body = asyncio.run(request(app, "POST", "/echo", body=b"\x00binary", headers=[(b"content-type", b"application/x")]))
+assert start["status"] == 201 and (b"content-type", b"application/x") in start["headers"], start
+assert body["body"] == b"\x00binary", body
+response = {}
+
+
+def start_response(status, headers):
+    response["status"], response["headers"] = status, headers
+
+
+environ = {
+    "REQUEST_METHOD": "POST",
+    "PATH_INFO": "/echo",
+    "CONTENT_LENGTH": "3",
+    "CONTENT_TYPE": "text/plain",
+    "HTTP_HOST": "example.test",
+    "wsgi.input": io.BytesIO(b"abc"),
+}
+assert app.wsgi(environ, start_response) == [b"abc"]
+assert response["status"] == "201 Created", response
+assert ("Content-Type", "text/plain") in response["headers"], response
+app.wsgi({"REQUEST_METHOD": "GET", "PATH_INFO": "/odd", "wsgi.input": io.BytesIO()}, start_response)
+assert response["status"] == "299 ", response
+
+assert lib.Greeting == "hello"
+lib.Greeting = "hi"
+assert lib.Hello("melo") == "hi melo", "assigning a variable from Python should reach Go"
+assert lib.Version == 1
+try:
+    lib.Version = 2
+except AttributeError:
+    pass
+else:
+    raise AssertionError("assigning a readonly variable should fail")
+assert lib.Version == 1
+
+assert lib.Half(-128) == -64
+for value in (128, -129):
+    try:
+        lib.Half(value)
+    except OverflowError:
+        pass
+    else:
+        raise AssertionError(f"{value} should not fit in an int8")
+assert lib.Double(2**100 + 1) == 2**101 + 2
+assert lib.Invert(fractions.Fraction(-3, 7)) == fractions.Fraction(-7, 3)
+assert lib.Conjugate(1.5 + 2j) == 1.5 - 2j
+
+entry = lib.Entry()
+try:
+    entry.Name
+except lib.GoError as error:
+    assert "embedded field Named is nil" in str(error), error
+else:
+    raise AssertionError("promoting through a nil pointer should fail")
+entry.Named = lib.Named()
+entry.Name = "melo"
+assert entry.Named.Name == "melo"
+print("ok")
+`
+
+func TestRoundTrip(t *testing.T) {
+	python, err := exec.LookPath("python3")
+	if err != nil {
+		t.Skip("python3 is needed by the round trip tests")
+	}
+
+	root := t.TempDir()
+	writeRoundTripFile(t, filepath.Join(root, "go.mod"), roundTripModule)
+	writeRoundTripFile(t, filepath.Join(root, "lib", "lib.go"), roundTripLibrary)
+
+	exportedPackage := files.ExportedPackage{GoPath: "example.com/roundtrip/lib", PythonPath: "lib"}
+	objects, err := generator.InspectPackage(root, exportedPackage.GoPath)
+	if err != nil {
+		t.Fatalf("InspectPackage should not return error, got %v", err)
+	}
+	bridge, err := generator.GenerateBridge(exportedPackage, objects)
+	if err != nil {
+		t.Fatalf("GenerateBridge should not return error, got %v", err)
+	}
+	module, err := generator.GeneratePythonModule(exportedPackage, objects)
+	if err != nil {
+		t.Fatalf("GeneratePythonModule should not return error, got %v", err)
+	}
+
+	output := filepath.Join(root, "out")
+	writeRoundTripFile(t, filepath.Join(root, "bridge", "runtime.go"), generator.BridgeRuntime)
+	writeRoundTripFile(t, filepath.Join(root, "bridge", "lib.go"), string(bridge))
+	writeRoundTripFile(t, filepath.Join(output, "_melo.py"), generator.PythonRuntime)
+	writeRoundTripFile(t, filepath.Join(output, "lib.py"), module)
+
+	build := exec.Command("go", "build", "-buildmode=c-shared", "-o", filepath.Join(output, "libmelo.so"), "./bridge")
+	build.Dir = root
+	build.Env = append(os.Environ(), "CGO_ENABLED=1", "GOWORK=off")
+	if combined, err := build.CombinedOutput(); err != nil {
+		t.Fatalf("the bridge should build, got %v\n%s", err, combined)
+	}
+
+	t.Run("should receive channels, pull sequences, feed iterables, render docstrings, wire dunder methods, close resources, stream bytes, serve HTTP, share variables, convert numbers, call Python objects and promote embedded members", func(t *testing.T) {
+		run := exec.Command(python, "-c", roundTripScript)
+		run.Dir = output
+		stderr := &strings.Builder{}
+		run.Stderr = stderr
+		stdout, err := run.Output()
+		if err != nil || strings.TrimSpace(string(stdout)) != "ok" {
+			t.Errorf("the Python script should print ok, got %v\n%s%s", err, stdout, stderr)
+		}
+		if expected := "melo_lib_Greeter_Greet raised in Python, returning zero values: no greeting"; !strings.Contains(stderr.String(), expected) {
+			t.Errorf("the bridge should log %q, got\n%s", expected, stderr)
+		}
+	})
+}
+
+func writeRoundTripFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,84 @@
+package generator
+
+import (
+	"go/ast"
+	"log"
+	"regexp"
+	"strings"
+)
+
+// meloTag is the struct tag customizing the Python attribute of a field, such
+// as `melo:"name=user_id,readonly"`, or `melo:"skip"` to leave it out.
+const meloTag = "melo"
+
+// structField is an exported field along with the Python attribute exposing
+// it, resolved from its struct tags.
+type structField struct {
+	ExportedField
+	Bridge     bridgeType
+	PythonName string
+	ReadOnly   bool
+}
+
+// exportedFields returns the fields of a struct exposed to Python, named by
+// their name directive or melo tag, then by their json tag when the package
+// opts in, then by their Go name in the style of the package. Fields of
+// unsupported types are left out.
+func (context *generatorContext) exportedFields(exportedStruct ExportedStruct) []structField {
+	fields := make([]structField, 0, len(exportedStruct.Fields))
+	for _, field := range exportedStruct.Fields {
+		if !ast.IsExported(field.Name) {
+			continue
+		}
+		fieldType := context.resolveType(field.Type)
+		if !fieldType.storable() {
+			continue
+		}
+		directives := parseDeclarationDirectives(field.Directives)
+		resolved := structField{ExportedField: field, Bridge: fieldType, PythonName: context.styledName(field.Name), ReadOnly: directives.ReadOnly}
+
+		if context.exportedPackage.JSONFieldNames {
+			if name, _, _ := strings.Cut(field.Tags["json"], ","); name == "-" {
+				continue
+			} else if name != "" {
+				resolved.PythonName = name
+			}
+		}
+
+		skip := false
+		if tag, ok := field.Tags[meloTag]; ok {
+			for _, option := range strings.Split(tag, ",") {
+				key, value, _ := strings.Cut(strings.TrimSpace(option), "=")
+				switch key {
+				case "name":
+					resolved.PythonName = value
+				case "skip":
+					skip = true
+				case "readonly":
+					resolved.ReadOnly = true
+				case "":
+				default:
+					log.Printf("Ignoring unknown %s tag option %q of field %s.%s", meloTag, key, exportedStruct.Name, field.Name)
+				}
+			}
+		}
+		if skip {
+			continue
+		}
+		if directives.Name != "" {
+			resolved.PythonName = directives.Name
+		}
+
+		if pythonKeywords[resolved.PythonName] {
+			resolved.PythonName += "_"
+		}
+		if !pythonIdentifierPattern.MatchString(resolved.PythonName) {
+			log.Printf("Keeping the Go name of field %s.%s: %q is not a Python identifier", exportedStruct.Name, field.Name, resolved.PythonName)
+			resolved.PythonName = field.Name
+		}
+		fields = append(fields, resolved)
+	}
+	return fields
+}
+
+var pythonIdentifierPattern = regexp.MustCompile(`^[A-Za-z_]\w*$`)
@@ -0,0 +1,891 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/EdmilsonRodrigues/melo-project/src/melo/files"
+)
+
+type typeKind int
+
+const (
+	unsupportedKind typeKind = iota
+	intKind
+	uintKind
+	floatKind
+	boolKind
+	stringKind
+	structKind
+	structPointerKind
+	interfaceKind
+	receiveChannelKind
+	feedChannelKind
+	sequenceKind
+	variadicKind
+	optionsKind
+	durationKind
+	readerKind
+	writerKind
+	handlerKind
+	contextKind
+	functionKind
+	anonymousStructKind
+	complexKind
+	bigIntKind
+	bigFloatKind
+	bigRatKind
+)
+
+// bridgeType describes how a Go type crosses the C boundary between the
+// generated bridge and the generated Python module.
+type bridgeType struct {
+	kind    typeKind
+	goType  string      // Go expression of the type inside the bridge source
+	basic   string      // Predeclared type underlying numbers, which sizes range checks
+	name    string      // Declaration name for structs and interfaces
+	symbol  string      // C symbol prefix for structs, interfaces, channels and sequences
+	element *bridgeType // Element type of channels, variadics and value type of sequences
+	key     *bridgeType // Key type of iter.Seq2 sequences
+	options []bridgeOption
+	fields  []bridgeArgument // Fields of anonymous structs
+	// Parameters and results of func types
+	signature *bridgeCall
+}
+
+// bridgeOption is an exported functional option constructor, exposed to
+// Python as a keyword argument of the functions accepting the option type.
+type bridgeOption struct {
+	Function string
+	Keyword  string
+	Value    *bridgeType // Nil for options without argument, exposed as flags
+}
+
+type bridgeArgument struct {
+	Name string
+	Type bridgeType
+}
+
+// bridgeCall is an exported routine resolved against the bridge types.
+type bridgeCall struct {
+	Name         string // Python name
+	GoName       string
+	Symbol       string
+	Arguments    []bridgeArgument
+	Results      []bridgeType
+	ReturnsError bool
+	Doc          string
+	Deprecated   string // Message of the Deprecated: paragraph of the doc
+	Examples     string // Doctests translated from the Go Example functions
+	Async        bool   // Rendered as a coroutine running the call in a thread
+}
+
+type generatorContext struct {
+	exportedPackage files.ExportedPackage
+	objects         ExportedObjects
+	alias           string
+	prefix          string
+	helpers         []bridgeType
+	instances       map[string]genericInstance
+	generics        []genericFunction
+	dependencies    map[string]*generatorContext
+	imports         map[string]string
+	reported        map[string]bool
+	// nilEmbeddings maps the members promoted through embedded pointers, as
+	// Struct.Member, to the selectors of those pointers.
+	nilEmbeddings map[string][]string
+	// unmatchedExclusions are the names of the exclude option matching no
+	// declaration.
+	unmatchedExclusions []string
+}
+
+func newGeneratorContext(exportedPackage files.ExportedPackage, objects ExportedObjects) *generatorContext {
+	alias := exportedPackage.PackageName
+	if alias == "" {
+		splittedGoPath := strings.Split(exportedPackage.GoPath, "/")
+		alias = splittedGoPath[len(splittedGoPath)-1]
+	}
+
+	context := &generatorContext{
+		exportedPackage: exportedPackage,
+		alias:           sanitizeIdentifier(alias),
+		prefix:          "melo_" + sanitizeIdentifier(exportedPackage.PythonPath),
+		instances:       map[string]genericInstance{},
+		dependencies:    map[string]*generatorContext{},
+		imports:         map[string]string{},
+		reported:        map[string]bool{},
+		nilEmbeddings:   map[string][]string{},
+	}
+	context.objects = context.promoteEmbedded(context.instantiateGenerics(context.excludeObjects(objects)))
+	return context
+}
+
+func (context *generatorContext) symbol(names ...string) string {
+	return strings.Join(append([]string{context.prefix}, names...), "_")
+}
+
+func (context *generatorContext) resolveType(typeName string) bridgeType {
+	switch typeName {
+	case "int", "int8", "int16", "int32", "int64":
+		return bridgeType{kind: intKind, goType: typeName, basic: typeName}
+	case "uint", "uint8", "uint16", "uint32", "uint64", "uintptr":
+		return bridgeType{kind: uintKind, goType: typeName, basic: typeName}
+	case "float32", "float64":
+		return bridgeType{kind: floatKind, goType: typeName, basic: typeName}
+	case "complex64", "complex128":
+		return bridgeType{kind: complexKind, goType: typeName, basic: typeName}
+	case "*math/big.Int":
+		return bridgeType{kind: bigIntKind, goType: "*big.Int"}
+	case "*math/big.Float":
+		return bridgeType{kind: bigFloatKind, goType: "*big.Float"}
+	case "*math/big.Rat":
+		return bridgeType{kind: bigRatKind, goType: "*big.Rat"}
+	case "bool":
+		return bridgeType{kind: boolKind, goType: typeName}
+	case "string":
+		return bridgeType{kind: stringKind, goType: typeName}
+	case "context.Context":
+		return bridgeType{kind: contextKind, goType: typeName}
+	case "time.Duration":
+		return bridgeType{kind: durationKind, goType: typeName}
+	case "io.Reader", "io.ReadCloser":
+		return bridgeType{kind: readerKind, goType: typeName}
+	case "io.Writer":
+		return bridgeType{kind: writerKind, goType: typeName}
+	case "net/http.Handler":
+		return bridgeType{kind: handlerKind, goType: "http.Handler"}
+	}
+
+	if strings.HasPrefix(typeName, "func(") {
+		return context.resolveFunction(typeName)
+	}
+	if strings.HasPrefix(typeName, "struct{") {
+		return context.resolveAnonymousStruct(typeName)
+	}
+	if elementName, ok := strings.CutPrefix(typeName, "<-chan "); ok {
+		return context.resolveChannel(elementName)
+	}
+	if elementName, ok := strings.CutPrefix(typeName, "chan<- "); ok {
+		return context.resolveSendChannel(elementName)
+	}
+	if elementName, ok := strings.CutPrefix(typeName, "chan "); ok {
+		return context.resolveChannel(elementName)
+	}
+	if elementName, ok := strings.CutPrefix(typeName, "iter.Seq["); ok {
+		return context.resolveSequence(strings.TrimSuffix(elementName, "]"))
+	}
+	if elementNames, ok := strings.CutPrefix(typeName, "iter.Seq2["); ok {
+		return context.resolveSequence(splitTopLevel(strings.TrimSuffix(elementNames, "]"), ',')...)
+	}
+
+	localName, pointer := strings.CutPrefix(typeName, "*")
+	localName, local := strings.CutPrefix(localName, context.exportedPackage.GoPath+".")
+	if !local {
+		return context.resolveDependencyType(typeName)
+	}
+	if strings.ContainsAny(localName, ".[]*") {
+		return bridgeType{kind: unsupportedKind, goType: typeName}
+	}
+	goType := context.alias + "." + context.goName(localName)
+
+	// Aliases are the very type they name, in Go as in Python.
+	if alias := findAliasByName(context.objects.ExportedAliases, localName); alias != nil {
+		if pointer {
+			return context.resolveType("*" + alias.Type)
+		}
+		return context.resolveType(alias.Type)
+	}
+
+	if findStructByName(context.objects.ExportedStructs, localName) != nil {
+		if pointer {
+			return bridgeType{kind: structPointerKind, goType: "*" + goType, name: localName, symbol: context.symbol(localName)}
+		}
+		return bridgeType{kind: structKind, goType: goType, name: localName, symbol: context.symbol(localName)}
+	}
+
+	if pointer {
+		return bridgeType{kind: unsupportedKind, goType: typeName}
+	}
+
+	if findInterfaceByName(context.objects.ExportedInterfaces, localName) != nil {
+		return bridgeType{kind: interfaceKind, goType: goType, name: localName, symbol: context.symbol(localName)}
+	}
+
+	for _, exportedType := range context.objects.ExportedTypes {
+		if exportedType.Name != localName {
+			continue
+		}
+		underlying := context.resolveType(exportedType.Type)
+		switch underlying.kind {
+		case intKind, uintKind, floatKind, complexKind, boolKind, stringKind:
+			underlying.goType = goType
+			return underlying
+		}
+	}
+
+	return bridgeType{kind: unsupportedKind, goType: typeName}
+}
+
+// resolveChannel resolves a channel type. Channels are received from when
+// returned by Go and fed from a Python iterable when passed to Go, so the
+// direction is settled by resolveRoutine.
+func (context *generatorContext) resolveChannel(elementName string) bridgeType {
+	element := context.resolveType(strings.Trim(elementName, "()"))
+	if !element.storable() {
+		return bridgeType{kind: unsupportedKind, goType: "chan " + elementName}
+	}
+	key := sanitizeIdentifier(strings.ReplaceAll(element.goType, "*", "ptr"))
+	return bridgeType{kind: receiveChannelKind, goType: "chan " + element.goType, name: key, element: &element}
+}
+
+// resolveSendChannel resolves a send-only channel, which can only be passed to
+// Go and is fed from a Python iterable like any other channel argument.
+func (context *generatorContext) resolveSendChannel(elementName string) bridgeType {
+	channel := context.resolveChannel(elementName)
+	if channel.kind == receiveChannelKind {
+		channel.kind = feedChannelKind
+	}
+	return channel
+}
+
+// resolveSequence resolves an iter.Seq from its value type, or an iter.Seq2
+// from its key and value types.
+func (context *generatorContext) resolveSequence(elementNames ...string) bridgeType {
+	unsupported := bridgeType{kind: unsupportedKind, goType: "iter.Seq"}
+	if len(elementNames) < 1 || len(elementNames) > 2 {
+		return unsupported
+	}
+
+	sequence := bridgeType{kind: sequenceKind}
+	keys := make([]string, 0, len(elementNames))
+	goTypes := make([]string, 0, len(elementNames))
+	for _, elementName := range elementNames {
+		element := context.resolveType(elementName)
+		if !element.storable() {
+			return unsupported
+		}
+		if sequence.element != nil {
+			key := *sequence.element
+			sequence.key = &key
+		}
+		sequence.element = &element
+		keys = append(keys, sanitizeIdentifier(strings.ReplaceAll(element.goType, "*", "ptr")))
+		goTypes = append(goTypes, element.goType)
+	}
+
+	sequence.name = strings.Join(keys, "_")
+	if sequence.key == nil {
+		sequence.goType = fmt.Sprintf("sequencePuller[%s]", goTypes[0])
+	} else {
+		sequence.goType = fmt.Sprintf("pairPuller[%s]", strings.Join(goTypes, ", "))
+	}
+	return sequence
+}
+
+// resolveVariadic resolves the slice type of a variadic argument, which is
+// passed from Python as a C array and its length.
+func (context *generatorContext) resolveVariadic(typeName string) bridgeType {
+	element := context.resolveType(strings.TrimPrefix(typeName, "[]"))
+	if !element.storable() {
+		return context.resolveOptions(strings.TrimPrefix(typeName, "[]"))
+	}
+	key := sanitizeIdentifier(strings.ReplaceAll(element.goType, "*", "ptr"))
+	return bridgeType{kind: variadicKind, goType: "[]" + element.goType, name: key, element: &element}
+}
+
+// resolveOptions resolves the functional options pattern, where a variadic
+// argument of an option type is built from the exported functions returning
+// only that type, such as WithTimeout or WithRetries.
+func (context *generatorContext) resolveOptions(typeName string) bridgeType {
+	localName, local := strings.CutPrefix(typeName, context.exportedPackage.GoPath+".")
+	if !local || strings.ContainsAny(localName, ".[]*") {
+		return bridgeType{kind: unsupportedKind, goType: typeName}
+	}
+
+	options := bridgeType{kind: optionsKind, goType: context.alias + "." + localName, name: localName}
+	for _, function := range context.objects.ExportedFunctions {
+		if len(function.ReturnTypes) != 1 || function.ReturnTypes[0] != typeName || len(function.Arguments) > 1 {
+			continue
+		}
+
+		option := bridgeOption{
+			Function: function.Name,
+			Keyword:  argumentName(snakeCase(strings.TrimPrefix(function.Name, "With")), 0),
+		}
+		if len(function.Arguments) == 1 {
+			value := context.resolveType(function.Arguments[0].Type)
+			if !value.storable() || function.Arguments[0].Variadic {
+				continue
+			}
+			option.Value = &value
+		}
+		options.options = append(options.options, option)
+	}
+
+	if len(options.options) == 0 {
+		return bridgeType{kind: unsupportedKind, goType: typeName}
+	}
+	return options
+}
+
+// useHelper records a type needing helpers rendered once per element type,
+// such as channels and sequences.
+func (context *generatorContext) useHelper(helper bridgeType) bridgeType {
+	switch helper.kind {
+	case receiveChannelKind:
+		helper.symbol = context.symbol("receiver", helper.name)
+	case feedChannelKind:
+		helper.symbol = context.symbol("feeder", helper.name)
+	case sequenceKind:
+		helper.symbol = context.symbol("sequence", helper.name)
+	case variadicKind:
+		helper.symbol = context.symbol("slice", helper.name)
+	case optionsKind:
+		helper.symbol = context.symbol("options", helper.name)
+	case handlerKind:
+		helper.symbol = context.symbol("http")
+	case functionKind:
+		helper.symbol = context.symbol("func", helper.name)
+	}
+	for _, used := range context.helpers {
+		if used.symbol == helper.symbol {
+			return helper
+		}
+	}
+	context.helpers = append(context.helpers, helper)
+	return helper
+}
+
+// storable reports whether values of the type can be copied across the
+// bridge, as struct fields, channel elements and interface method values.
+func (bridge bridgeType) storable() bool {
+	switch bridge.kind {
+	case intKind, uintKind, floatKind, boolKind, stringKind, structKind, structPointerKind, durationKind:
+		return true
+	case complexKind, bigIntKind, bigFloatKind, bigRatKind:
+		return true
+	}
+	return false
+}
+
+// aliasable reports whether a Go type alias of the type can be bound to the
+// Python class or type the type maps to.
+func (bridge bridgeType) aliasable() bool {
+	switch bridge.kind {
+	case intKind, uintKind, floatKind, boolKind, stringKind, structKind, structPointerKind, interfaceKind, durationKind:
+		return true
+	case complexKind, bigIntKind, bigFloatKind, bigRatKind:
+		return true
+	}
+	return false
+}
+
+// hidden reports whether the argument is supplied by the bridge instead of
+// appearing in the Python signature.
+func (bridge bridgeType) hidden() bool {
+	return bridge.kind == contextKind
+}
+
+// resolveRoutine resolves every argument and result of a routine, reporting
+// false when one of them cannot cross the bridge.
+func (context *generatorContext) resolveRoutine(routine ExportedRoutine, symbol string) (call bridgeCall, ok bool) {
+	call = bridgeCall{
+		Name:       context.pythonRoutineName(routine),
+		GoName:     routine.Name,
+		Symbol:     symbol,
+		Doc:        context.pythonDoc(routine.Doc),
+		Deprecated: deprecationMessage(routine.Doc),
+		Async:      parseDeclarationDirectives(routine.Directives).Async,
+	}
+
+	for index, argument := range routine.Arguments {
+		argumentType := context.resolveType(argument.Type)
+		if argument.Variadic {
+			argumentType = context.resolveVariadic(argument.Type)
+		}
+		if argumentType.kind == unsupportedKind {
+			return call, false
+		}
+		if argumentType.kind == interfaceKind && !context.proxyable(*findInterfaceByName(context.objects.ExportedInterfaces, argumentType.name)) {
+			return call, false
+		}
+		if argumentType.kind == receiveChannelKind {
+			argumentType.kind = feedChannelKind
+		}
+		if argumentType.kind == readerKind && argumentType.goType != "io.Reader" {
+			return call, false
+		}
+		call.Arguments = append(call.Arguments, bridgeArgument{
+			Name: argumentName(argument.Name, index),
+			Type: argumentType,
+		})
+	}
+
+	returnTypes := routine.ReturnTypes
+	if len(returnTypes) > 0 && returnTypes[len(returnTypes)-1] == "error" {
+		call.ReturnsError = true
+		returnTypes = returnTypes[:len(returnTypes)-1]
+	}
+
+	for _, returnType := range returnTypes {
+		resultType := context.resolveType(returnType)
+		if resultType.kind == handlerKind && !parseDeclarationDirectives(routine.Directives).ASGI {
+			return call, false
+		}
+		switch resultType.kind {
+		case receiveChannelKind, sequenceKind, readerKind, handlerKind, functionKind, anonymousStructKind:
+		default:
+			if !resultType.storable() {
+				return call, false
+			}
+		}
+		call.Results = append(call.Results, resultType)
+	}
+
+	// Anonymous structs are named after where they appear, such as
+	// Configure_options_Struct for the options argument of Configure.
+	routineName := strings.TrimPrefix(symbol, context.prefix+"_")
+	for index, argument := range call.Arguments {
+		switch argument.Type.kind {
+		case feedChannelKind, variadicKind, optionsKind, functionKind:
+			call.Arguments[index].Type = context.useHelper(argument.Type)
+		case anonymousStructKind:
+			call.Arguments[index].Type = context.nameAnonymousStruct(argument.Type, routineName, argument.Name)
+		}
+	}
+	for index, result := range call.Results {
+		switch result.kind {
+		case receiveChannelKind, sequenceKind, handlerKind, functionKind:
+			call.Results[index] = context.useHelper(result)
+		case anonymousStructKind:
+			resultName := "Result"
+			if len(call.Results) > 1 {
+				resultName = fmt.Sprintf("Result%d", index)
+			}
+			call.Results[index] = context.nameAnonymousStruct(result, routineName, resultName)
+		}
+	}
+	for _, argument := range call.Arguments {
+		if argument.Type.kind == unsupportedKind {
+			return call, false
+		}
+	}
+	for _, result := range call.Results {
+		if result.kind == unsupportedKind {
+			return call, false
+		}
+	}
+
+	return call, true
+}
+
+func (bridge bridgeType) cType() string {
+	switch bridge.kind {
+	case intKind, durationKind:
+		return "C.longlong"
+	case uintKind:
+		return "C.ulonglong"
+	case floatKind:
+		return "C.double"
+	case boolKind:
+		return "C.bool"
+	case stringKind, complexKind, bigIntKind, bigFloatKind, bigRatKind:
+		return "*C.char"
+	case variadicKind:
+		return "*" + bridge.element.cType()
+	default:
+		return "C.uintptr_t"
+	}
+}
+
+// cDeclaration is the plain C spelling of the type, used in callback typedefs.
+func (bridge bridgeType) cDeclaration() string {
+	switch bridge.kind {
+	case intKind, durationKind:
+		return "long long"
+	case uintKind:
+		return "unsigned long long"
+	case floatKind:
+		return "double"
+	case boolKind:
+		return "bool"
+	case stringKind, complexKind, bigIntKind, bigFloatKind, bigRatKind:
+		return "char*"
+	default:
+		return "uintptr_t"
+	}
+}
+
+func (bridge bridgeType) toGo(expression string) string {
+	switch bridge.kind {
+	case intKind, uintKind, floatKind, boolKind, durationKind:
+		return fmt.Sprintf("%s(%s)", bridge.goType, expression)
+	case stringKind:
+		if bridge.goType == "string" {
+			return fmt.Sprintf("C.GoString(%s)", expression)
+		}
+		return fmt.Sprintf("%s(C.GoString(%s))", bridge.goType, expression)
+	case complexKind, bigIntKind, bigFloatKind, bigRatKind:
+		return bridge.numberToGo(expression)
+	case structKind:
+		return fmt.Sprintf("*cgo.Handle(%s).Value().(*%s)", expression, bridge.goType)
+	case structPointerKind:
+		return fmt.Sprintf("cgo.Handle(%s).Value().(%s)", expression, bridge.goType)
+	case interfaceKind, functionKind:
+		return fmt.Sprintf("%s(%s)", proxyConstructorName(bridge.symbol), expression)
+	case anonymousStructKind:
+		fields := make([]string, 0, len(bridge.fields))
+		for index, parameter := range bridge.fieldParameters(expression) {
+			fields = append(fields, fmt.Sprintf("%s: %s", bridge.fields[index].Name, bridge.fields[index].Type.toGo(parameter)))
+		}
+		return fmt.Sprintf("%s{%s}", bridge.goType, strings.Join(fields, ", "))
+	case feedChannelKind:
+		return fmt.Sprintf("%s_feed(ctx, fed, %s)", bridge.symbol, expression)
+	case variadicKind:
+		return fmt.Sprintf("%s(%s, %sLength)...", bridge.symbol, expression, expression)
+	case readerKind:
+		return fmt.Sprintf("newPythonReader(%s)", expression)
+	case writerKind:
+		return fmt.Sprintf("newPythonWriter(%s)", expression)
+	case optionsKind:
+		return fmt.Sprintf("%s(%s)...", bridge.symbol, strings.Join(bridge.optionParameters(expression), ", "))
+	case contextKind:
+		return "ctx"
+	}
+	return expression
+}
+
+// toC converts a Go variable into its C representation. Handles are always
+// created around pointers so that methods with pointer receivers keep working.
+func (bridge bridgeType) toC(variable string) string {
+	switch bridge.kind {
+	case stringKind:
+		if bridge.goType == "string" {
+			return fmt.Sprintf("C.CString(%s)", variable)
+		}
+		return fmt.Sprintf("C.CString(string(%s))", variable)
+	case complexKind, bigIntKind, bigFloatKind, bigRatKind:
+		return bridge.numberToC(variable)
+	case structKind:
+		return fmt.Sprintf("C.uintptr_t(cgo.NewHandle(&%s))", variable)
+	case structPointerKind:
+		return fmt.Sprintf("C.uintptr_t(cgo.NewHandle(%s))", variable)
+	case receiveChannelKind:
+		return fmt.Sprintf("C.uintptr_t(cgo.NewHandle(newReceiver(%s, cancel, drain, fed)))", variable)
+	case readerKind:
+		return fmt.Sprintf("C.uintptr_t(cgo.NewHandle(newGoReader(%s)))", variable)
+	case handlerKind:
+		return fmt.Sprintf("C.uintptr_t(cgo.NewHandle(%s))", variable)
+	case functionKind:
+		return fmt.Sprintf("C.uintptr_t(cgo.NewHandle(&%s))", variable)
+	case sequenceKind:
+		if bridge.key != nil {
+			return fmt.Sprintf("C.uintptr_t(cgo.NewHandle(newPairPuller(%s)))", variable)
+		}
+		return fmt.Sprintf("C.uintptr_t(cgo.NewHandle(newSequencePuller(%s)))", variable)
+	}
+	return fmt.Sprintf("%s(%s)", bridge.cType(), variable)
+}
+
+// ctypesType is the ctypes declaration used when the value is an argument.
+func (bridge bridgeType) ctypesType() string {
+	switch bridge.kind {
+	case intKind, durationKind:
+		return "ctypes.c_longlong"
+	case uintKind:
+		return "ctypes.c_ulonglong"
+	case floatKind:
+		return "ctypes.c_double"
+	case boolKind:
+		return "ctypes.c_bool"
+	case stringKind, complexKind, bigIntKind, bigFloatKind, bigRatKind:
+		return "ctypes.c_char_p"
+	default:
+		return "ctypes.c_size_t"
+	}
+}
+
+// ctypesResultType is the ctypes declaration used for out parameters, where
+// strings are kept as raw pointers so that they can be freed.
+func (bridge bridgeType) ctypesResultType() string {
+	if bridge.textual() {
+		return "ctypes.c_void_p"
+	}
+	return bridge.ctypesType()
+}
+
+func (bridge bridgeType) pythonType() string {
+	switch bridge.kind {
+	case intKind, uintKind, bigIntKind:
+		return "int"
+	case floatKind:
+		return "float"
+	case complexKind:
+		return "complex"
+	case bigFloatKind:
+		return "decimal.Decimal"
+	case bigRatKind:
+		return "fractions.Fraction"
+	case boolKind:
+		return "bool"
+	case stringKind:
+		return "str"
+	case structKind, structPointerKind, interfaceKind:
+		return bridge.name
+	case receiveChannelKind:
+		return fmt.Sprintf("ReceiveChannel[%s]", bridge.element.pythonType())
+	case feedChannelKind:
+		return fmt.Sprintf("typing.Iterable[%s]", bridge.element.pythonType())
+	case sequenceKind:
+		return fmt.Sprintf("typing.Generator[%s, None, None]", bridge.sequenceValueType())
+	case durationKind:
+		return "datetime.timedelta"
+	case readerKind:
+		return "Readable"
+	case handlerKind:
+		return "Handler"
+	case writerKind:
+		return "Writable"
+	case functionKind:
+		parameters := make([]string, 0, len(bridge.signature.Arguments))
+		for _, argument := range bridge.signature.Arguments {
+			parameters = append(parameters, argument.Type.pythonType())
+		}
+		return fmt.Sprintf("typing.Callable[[%s], %s]", strings.Join(parameters, ", "), pythonReturnType(bridge.signature.Results))
+	case anonymousStructKind:
+		return bridge.name
+	}
+	return "typing.Any"
+}
+
+// pythonClasses are the Python classes accepted for an argument, used to
+// dispatch calls to generic functions, or empty when they cannot be checked.
+func (bridge bridgeType) pythonClasses() string {
+	switch bridge.kind {
+	case intKind, uintKind, floatKind, boolKind, stringKind, structKind, structPointerKind, interfaceKind, durationKind:
+		return bridge.pythonType()
+	case complexKind, bigIntKind, bigFloatKind, bigRatKind:
+		return bridge.pythonType()
+	case variadicKind:
+		return bridge.element.pythonClasses()
+	}
+	return ""
+}
+
+// pythonResultType is the Python type of the values returned by Go, which
+// differs from the accepted arguments for io.Reader.
+func (bridge bridgeType) pythonResultType() string {
+	if bridge.kind == readerKind {
+		return "GoReader"
+	}
+	return bridge.pythonType()
+}
+
+// toPython converts a raw ctypes value into the Python value.
+func (bridge bridgeType) toPython(expression string) string {
+	switch bridge.kind {
+	case stringKind:
+		return fmt.Sprintf("_string(%s)", expression)
+	case complexKind, bigIntKind, bigFloatKind, bigRatKind:
+		return fmt.Sprintf("_number(%s, %s)", expression, bridge.pythonParser())
+	case structKind, structPointerKind:
+		return fmt.Sprintf("%s._from_handle(%s)", bridge.name, expression)
+	case receiveChannelKind:
+		return fmt.Sprintf("%s._from_handle(%s)", receiverClassName(bridge), expression)
+	case sequenceKind:
+		return fmt.Sprintf("iter(%s._from_handle(%s))", sequenceClassName(bridge), expression)
+	case durationKind:
+		return fmt.Sprintf("datetime.timedelta(microseconds=%s / 1000)", expression)
+	case readerKind:
+		return fmt.Sprintf("GoReader._from_handle(%s)", expression)
+	case handlerKind:
+		return fmt.Sprintf("_Handler._from_handle(%s)", expression)
+	case functionKind:
+		return fmt.Sprintf("%s._from_handle(%s)", functionClassName(bridge), expression)
+	}
+	return expression
+}
+
+// fromPython converts a Python value into the raw ctypes value.
+func (bridge bridgeType) fromPython(expression string) string {
+	switch bridge.kind {
+	case intKind, uintKind:
+		low, high := bridge.integerRange()
+		return fmt.Sprintf("_bounded(%s, %s, %s)", expression, low, high)
+	case stringKind:
+		return fmt.Sprintf("%s.encode()", expression)
+	case complexKind, bigIntKind, bigFloatKind, bigRatKind:
+		return fmt.Sprintf("%s(%s)", bridge.pythonFormatter(), expression)
+	case structKind, structPointerKind:
+		return fmt.Sprintf("%s._handle", expression)
+	case interfaceKind:
+		return fmt.Sprintf("_ref(%s)", expression)
+	case feedChannelKind:
+		return fmt.Sprintf("_ref(iter(%s))", expression)
+	case readerKind, writerKind, functionKind:
+		return fmt.Sprintf("_ref(%s)", expression)
+	case durationKind:
+		return fmt.Sprintf("%s // datetime.timedelta(microseconds=1) * 1000", expression)
+	}
+	return expression
+}
+
+func (bridge bridgeType) pythonString(expression string) string {
+	if bridge.textual() {
+		return fmt.Sprintf("_lib.melo_string(%s)", bridge.fromPython(expression))
+	}
+	return bridge.fromPython(expression)
+}
+
+// optionParameters are the names of the C parameters carrying the options of
+// an argument, a flag telling whether each option is set followed by its value.
+func (bridge bridgeType) optionParameters(expression string) []string {
+	parameters := []string{}
+	for index, option := range bridge.options {
+		parameters = append(parameters, fmt.Sprintf("%sSet%d", expression, index))
+		if option.Value != nil {
+			parameters = append(parameters, fmt.Sprintf("%sValue%d", expression, index))
+		}
+	}
+	return parameters
+}
+
+func argumentName(name string, index int) string {
+	if name == "" || name == "_" {
+		return fmt.Sprintf("arg%d", index)
+	}
+	if pythonKeywords[name] {
+		return name + "_"
+	}
+	return name
+}
+
+func sanitizeIdentifier(name string) string {
+	return strings.Map(func(character rune) rune {
+		if unicode.IsLetter(character) || unicode.IsDigit(character) || character == '_' {
+			return character
+		}
+		return '_'
+	}, name)
+}
+
+// snakeCase converts a Go identifier such as MaxRetries or HTTPClient into
+// its Python spelling, max_retries or http_client.
+func snakeCase(name string) string {
+	runes := []rune(name)
+	builder := strings.Builder{}
+	for index, character := range runes {
+		if unicode.IsUpper(character) && index > 0 {
+			previous := runes[index-1]
+			nextIsLower := index+1 < len(runes) && unicode.IsLower(runes[index+1])
+			if unicode.IsLower(previous) || unicode.IsDigit(previous) || (unicode.IsUpper(previous) && nextIsLower) {
+				builder.WriteRune('_')
+			}
+		}
+		builder.WriteRune(unicode.ToLower(character))
+	}
+	return builder.String()
+}
+
+func receiverClassName(channel bridgeType) string {
+	return "_Receiver_" + channel.name
+}
+
+func feederCallbackName(channel bridgeType) string {
+	return "_feeder_" + channel.name
+}
+
+func functionClassName(function bridgeType) string {
+	return "_Func_" + function.name
+}
+
+func sequenceClassName(sequence bridgeType) string {
+	return "_Sequence_" + sequence.name
+}
+
+// sequenceValueType is the Python type yielded by a sequence, a tuple of the
+// key and the value for iter.Seq2.
+func (bridge bridgeType) sequenceValueType() string {
+	if bridge.key != nil {
+		return fmt.Sprintf("tuple[%s, %s]", bridge.key.pythonType(), bridge.element.pythonType())
+	}
+	return bridge.element.pythonType()
+}
+
+// pullResults are the values returned by pulling once from a sequence.
+func (bridge bridgeType) pullResults() []bridgeType {
+	results := []bridgeType{*bridge.element, {kind: boolKind, goType: "bool"}}
+	if bridge.key != nil {
+		results = append([]bridgeType{*bridge.key}, results...)
+	}
+	return results
+}
+
+// constructorOf reports the struct built by a NewX constructor function,
+// returning X or *X and optionally an error, along with the variant suffix
+// of constructors such as NewXFromY, empty for the primary constructor.
+func (context *generatorContext) constructorOf(function ExportedRoutine) (structName, variant string, ok bool) {
+	returnTypes := function.ReturnTypes
+	if len(returnTypes) == 2 && returnTypes[1] == "error" {
+		returnTypes = returnTypes[:1]
+	}
+	if len(returnTypes) != 1 {
+		return "", "", false
+	}
+
+	structName, local := strings.CutPrefix(strings.TrimPrefix(returnTypes[0], "*"), context.exportedPackage.GoPath+".")
+	if !local || findStructByName(context.objects.ExportedStructs, structName) == nil {
+		return "", "", false
+	}
+	variant, constructor := strings.CutPrefix(function.Name, "New"+structName)
+	if !constructor || (variant != "" && !unicode.IsUpper([]rune(variant)[0])) {
+		return "", "", false
+	}
+	return structName, variant, true
+}
+
+func findFunctionByName(functions []ExportedRoutine, name string) *ExportedRoutine {
+	for index := range functions {
+		if functions[index].Name == name {
+			return &functions[index]
+		}
+	}
+	return nil
+}
+
+func findAliasByName(aliases []ExportedAlias, name string) *ExportedAlias {
+	for index := range aliases {
+		if aliases[index].Name == name {
+			return &aliases[index]
+		}
+	}
+	return nil
+}
+
+func findInterfaceByName(interfaces []ExportedInterface, name string) *ExportedInterface {
+	for index := range interfaces {
+		if interfaces[index].Name == name {
+			return &interfaces[index]
+		}
+	}
+	return nil
+}
+
+var pythonBuiltins = map[string]bool{
+	"abs": true, "all": true, "any": true, "bool": true, "bytes": true, "callable": true,
+	"dict": true, "enumerate": true, "float": true, "getattr": true, "hasattr": true,
+	"int": true, "isinstance": true, "iter": true, "len": true, "list": true, "map": true,
+	"max": true, "min": true, "next": true, "object": true, "open": true, "print": true,
+	"property": true, "range": true, "repr": true, "set": true, "setattr": true,
+	"str": true, "sum": true, "super": true, "tuple": true, "type": true, "zip": true,
+}
+
+var pythonKeywords = map[string]bool{
+	"False": true, "None": true, "True": true, "and": true, "as": true, "assert": true,
+	"async": true, "await": true, "class": true, "continue": true, "def": true,
+	"del": true, "elif": true, "else": true, "except": true, "finally": true,
+	"for": true, "from": true, "global": true, "if": true, "import": true, "in": true,
+	"is": true, "lambda": true, "nonlocal": true, "not": true, "or": true, "pass": true,
+	"raise": true, "return": true, "try": true, "while": true, "with": true, "yield": true,
+}
@@ -4,9 +4,12 @@ type ExportedObjects struct {
 	ExportedConstants  []ExportedConstant
 	ExportedVariables  []ExportedVariable
 	ExportedTypes      []ExportedType
+	ExportedAliases    []ExportedAlias
 	ExportedStructs    []ExportedStruct
 	ExportedInterfaces []ExportedInterface
 	ExportedFunctions  []ExportedRoutine
+	ExportedExamples   []ExportedExample
+	GoDeclarations     map[string]GoDeclaration
 }
 
 type ExportedConstant struct {
@@ -17,10 +20,11 @@ type ExportedConstant struct {
 }
 
 type ExportedVariable struct {
-	Name  string
-	Type  string
-	Value any
-	Doc   string
+	Name       string
+	Type       string
+	Value      any
+	Doc        string
+	Directives []string
 }
 
 type ExportedType struct {
@@ -29,22 +33,34 @@ type ExportedType struct {
 	Doc  string
 }
 
-type ExportedField struct {
+type ExportedAlias struct {
 	Name string
 	Type string
 	Doc  string
 }
 
+type ExportedField struct {
+	Name       string
+	Type       string
+	Doc        string
+	Embedded   bool
+	Tags       map[string]string
+	Directives []string
+}
+
 type ExportedArgument struct {
-	Name string
-	Type string
+	Name     string
+	Type     string
+	Variadic bool
 }
 
 type ExportedRoutine struct {
-	Name        string
-	Arguments   []ExportedArgument
-	ReturnTypes []string
-	Doc         string
+	Name           string
+	Arguments      []ExportedArgument
+	ReturnTypes    []string
+	Doc            string
+	Directives     []string
+	TypeParameters []string
 }
 
 type ExportedInterface struct {
@@ -54,8 +70,37 @@ type ExportedInterface struct {
 }
 
 type ExportedStruct struct {
-	Name    string
-	Fields  []ExportedField
-	Methods []ExportedRoutine
-	Doc     string
+	Name           string
+	Fields         []ExportedField
+	Methods        []ExportedRoutine
+	Doc            string
+	Directives     []string
+	TypeParameters []string
+}
+
+// ExportedExample is a Go Example function, read as a sequence of calls of
+// the package functions. Problem explains why an example cannot be read so.
+type ExportedExample struct {
+	Name      string
+	Suffix    string
+	Calls     []ExampleCall
+	Output    string
+	HasOutput bool
+	Unordered bool
+	Problem   string
+}
+
+// ExampleCall is a call of a package function with literal arguments, kept
+// as Go source. Printed calls are the single argument of fmt.Println.
+type ExampleCall struct {
+	Function  string
+	Arguments []string
+	Printed   bool
+}
+
+// GoDeclaration is the Go source of an exported declaration, without its doc
+// comment and body, along with the file and line it is declared at.
+type GoDeclaration struct {
+	Source   string
+	Position string
 }
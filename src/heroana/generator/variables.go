@@ -0,0 +1,108 @@
+package generator
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/EdmilsonRodrigues/melo-project/src/melo/files"
+)
+
+// readonlyDirective forbids assigning a package variable or a struct field
+// from Python.
+const readonlyDirective = files.ReadOnlyDirective
+
+// packageVariable is an exported package variable of a bridgeable type,
+// read and written live from Python.
+type packageVariable struct {
+	ExportedVariable
+	Bridge   bridgeType
+	ReadOnly bool
+}
+
+// exportedVariables returns the package variables exposed to Python. Their
+// values are not captured: every access goes through the bridge.
+func (context *generatorContext) exportedVariables() []packageVariable {
+	variables := make([]packageVariable, 0, len(context.objects.ExportedVariables))
+	for _, variable := range context.objects.ExportedVariables {
+		variableType := context.resolveType(variable.Type)
+		if !variableType.storable() {
+			log.Printf("Skipping variable %s: unsupported type %s", variable.Name, variable.Type)
+			continue
+		}
+		variables = append(variables, packageVariable{
+			ExportedVariable: variable,
+			Bridge:           variableType,
+			ReadOnly:         parseDeclarationDirectives(variable.Directives).ReadOnly,
+		})
+	}
+	return variables
+}
+
+// writeVariableBridge exports the getter of a package variable, and its
+// setter unless it is read-only.
+func (context *generatorContext) writeVariableBridge(body *strings.Builder, variable packageVariable) {
+	goName := context.alias + "." + variable.Name
+	writeExportFunction(body, exportFunction{
+		Symbol:  context.symbol("get", variable.Name),
+		Results: []bridgeType{variable.Bridge},
+		Invoke: func([]string) string {
+			return goName
+		},
+	})
+	if variable.ReadOnly {
+		return
+	}
+	writeExportFunction(body, exportFunction{
+		Symbol:    context.symbol("set", variable.Name),
+		Arguments: []bridgeArgument{{Name: "value", Type: variable.Bridge}},
+		Invoke: func(arguments []string) string {
+			return fmt.Sprintf("%s = %s", goName, arguments[0])
+		},
+	})
+}
+
+// writeModuleProperties renders the package variables as properties of the
+// module class, so that reading or assigning them from Python reaches the Go
+// variable. Read-only variables have no setter and raise AttributeError.
+func (context *generatorContext) writeModuleProperties(declarations, definitions *strings.Builder, variables []packageVariable) {
+	if len(variables) == 0 {
+		return
+	}
+
+	bodyIndent := pythonIndent + pythonIndent
+	definitions.WriteString("class _Module(types.ModuleType):\n")
+	for _, variable := range variables {
+		getter := bridgeCall{Symbol: context.symbol("get", variable.Name), Results: []bridgeType{variable.Bridge}}
+		writeCallDeclaration(declarations, getter, false)
+
+		deprecated := deprecationMessage(variable.Doc)
+		fmt.Fprintf(definitions, "%s@property\n", pythonIndent)
+		writeDeprecated(definitions, pythonIndent, deprecated)
+		fmt.Fprintf(definitions, "%sdef %s(self) -> %s:\n", pythonIndent, variable.Name, variable.Bridge.pythonResultType())
+		writeDocstring(definitions, bodyIndent, context.pythonDoc(variable.Doc))
+		writeDeprecationWarning(definitions, bodyIndent, variable.Name, deprecated)
+		fmt.Fprintf(definitions, "%s_out0 = %s()\n", bodyIndent, variable.Bridge.ctypesResultType())
+		fmt.Fprintf(definitions, "%s_check(_lib.%s(ctypes.byref(_out0)))\n", bodyIndent, getter.Symbol)
+		fmt.Fprintf(definitions, "%sreturn %s\n\n", bodyIndent, variable.Bridge.toPython("_out0.value"))
+		if variable.ReadOnly {
+			continue
+		}
+
+		setter := bridgeCall{Symbol: context.symbol("set", variable.Name), Arguments: []bridgeArgument{{Name: "value", Type: variable.Bridge}}}
+		writeCallDeclaration(declarations, setter, false)
+
+		fmt.Fprintf(definitions, "%s@%s.setter\n", pythonIndent, variable.Name)
+		writeDeprecated(definitions, pythonIndent, deprecated)
+		fmt.Fprintf(definitions, "%sdef %s(self, value: %s) -> None:\n", pythonIndent, variable.Name, variable.Bridge.pythonType())
+		writeDeprecationWarning(definitions, pythonIndent+pythonIndent, variable.Name, deprecated)
+		fmt.Fprintf(definitions, "%s_check(_lib.%s(%s))\n\n", bodyIndent, setter.Symbol, variable.Bridge.fromPython("value"))
+	}
+	trimTrailingBlankLines(definitions)
+	definitions.WriteString("\n\nsys.modules[__name__].__class__ = _Module\n\n")
+
+	for _, variable := range variables {
+		fmt.Fprintf(definitions, "%s: %s\n", variable.Name, variable.Bridge.pythonResultType())
+	}
+	definitions.WriteString("\n\n")
+}